@@ -1,17 +1,19 @@
 package main
 
 import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"voronoi/config"
+	"voronoi/voronoi"
+
 	ebiten "github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
-// VoronoiDiagram is the voronoi engine
-type VoronoiDiagram interface {
-	Init()
-	Tessellate(hideIterations bool) error
-	ToPixels() []byte
-}
-
 // Canvas handles the canvas visualization
 type Canvas struct {
 
@@ -22,26 +24,66 @@ type Canvas struct {
 	gameRunning    bool
 	hideIterations bool
 
-	voronoi VoronoiDiagram
-}
+	// comparison mode: shows the error overlay against the exact brute force diagram
+	comparing bool
+	reference *voronoi.Diagram // exact diagram with the current seeds (nil if not computed yet)
+
+	reported bool // true if the result of the current tessellation has been reported
+
+	// Delaunay triangulation overlay
+	triangulating bool
+	triangulation *voronoi.Triangulation // triangulation of the current seeds (nil if not computed yet)
+
+	// Lloyd relaxation: when active, the seeds are moved to the centroids of their cells at the end of each tessellation
+	relaxing        bool
+	relaxSteps      int     // steps done by the current relaxation
+	relaxIterations int     // maximum number of steps of a relaxation
+	relaxTolerance  float64 // the relaxation stops when no seed moves more than this (in pixels)
+
+	dragging     int // index of the seed being dragged with the mouse (-1 if none)
+	dragX, dragY int // position of the seed being dragged
+
+	random *rand.Rand // source of the random colors and velocities given to the seeds by the viewer, reproducible from the random seed
+
+	history *History // sets of seeds shown, to undo and redo their changes
+
+	// kinetic mode: when active, the seeds move along their velocities at every tick
+	motion *voronoi.Motion // motion of the seeds (nil if they are still)
+	speed  float64         // speed of the random velocities (pixels per second)
+	border voronoi.Border  // behavior of the moving seeds at the borders of the canvas
 
-// NewCanvas creates a canvas with a voronoi ready to start
-func NewCanvas(
-	width int,
-	height int,
-	hideIterations bool,
-	voronoi VoronoiDiagram,
-) (*Canvas, error) {
+	triangulationFile string // file the Delaunay triangulation is exported to
+	stateFile         string // file the state of the diagram is saved to
 
-	voronoi.Init()
+	voronoi voronoi.Engine
+}
+
+// NewCanvas creates a canvas showing the given voronoi engine, set up as the config says
+func NewCanvas(cfg *config.Config, v voronoi.Engine) (*Canvas, error) {
 
 	g := &Canvas{
-		width:          width,
-		height:         height,
-		gameRunning:    true,
-		hideIterations: hideIterations,
-		voronoi:        voronoi,
+		width:             cfg.Width,
+		height:            cfg.Height,
+		gameRunning:       true,
+		dragging:          -1,
+		history:           NewHistory(cfg.HistorySize),
+		speed:             cfg.Speed,
+		border:            cfg.MotionBorder(),
+		hideIterations:    cfg.HideIterations,
+		relaxIterations:   cfg.RelaxIterations,
+		relaxTolerance:    cfg.RelaxTolerance,
+		triangulationFile: cfg.TriangulationFile,
+		stateFile:         cfg.StateFile,
+		voronoi:           v,
+	}
+
+	// the first set of seeds comes from the configured random seed
+	g.newSeeds(v.RandSeed())
+
+	if cfg.Kinetic {
+		g.startMotion()
 	}
+
 	return g, nil
 }
 
@@ -53,22 +95,571 @@ func (g *Canvas) Update() error {
 		g.gameRunning = !g.gameRunning
 	}
 
+	// Intercepts the K key and starts/stops moving the seeds along their velocities
+	if inpututil.IsKeyJustPressed(ebiten.KeyK) {
+		if g.motion == nil {
+			g.startMotion()
+		} else {
+			g.motion = nil
+			g.pushHistory()
+		}
+	}
+
 	// Intercepts the Space key
 	// and restarts the execution regenerating the seeds
 	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
-		g.voronoi.Init()
+		g.newSeeds(time.Now().UnixNano())
+		g.relaxing = false
+	}
+
+	// Intercepts Ctrl+Z and Ctrl+Y (Cmd on macOS), and moves back and forward in the history of the seeds
+	if ebiten.IsKeyPressed(ebiten.KeyControl) || ebiten.IsKeyPressed(ebiten.KeyMeta) {
+		if inpututil.IsKeyJustPressed(ebiten.KeyZ) {
+			if snapshot, ok := g.history.Undo(); ok {
+				logError("Cannot restore the seeds", g.restoreSeeds(snapshot))
+			}
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyY) {
+			if snapshot, ok := g.history.Redo(); ok {
+				logError("Cannot restore the seeds", g.restoreSeeds(snapshot))
+			}
+		}
+	}
+
+	// Intercepts the D key and regenerates the seeds with the next distribution,
+	// keeping the same random seed
+	if inpututil.IsKeyJustPressed(ebiten.KeyD) {
+		g.nextSeedGenerator()
+		g.relaxing = false
 	}
 
+	// Intercepts the T key and toggles the overlay of the Delaunay triangulation
+	if inpututil.IsKeyJustPressed(ebiten.KeyT) {
+		g.triangulating = !g.triangulating
+	}
+
+	// Intercepts the O key and exports the Delaunay triangulation as an OBJ file
+	if inpututil.IsKeyJustPressed(ebiten.KeyO) {
+		logError("Cannot export the triangulation", g.exportTriangulation(g.triangulationFile))
+	}
+
+	// Intercepts the S key and saves the state of the diagram (completing the tessellation)
+	if inpututil.IsKeyJustPressed(ebiten.KeyS) {
+		logError("Cannot save the state", g.saveState(g.stateFile))
+	}
+
+	// Intercepts the L key and starts/stops the Lloyd relaxation
+	if inpututil.IsKeyJustPressed(ebiten.KeyL) {
+		g.relaxing = !g.relaxing
+		g.relaxSteps = 0
+		if !g.relaxing {
+			g.pushHistory()
+		}
+	}
+
+	// Intercepts the R key and runs a single step of the Lloyd relaxation
+	if inpututil.IsKeyJustPressed(ebiten.KeyR) {
+		_, err := g.relax()
+		logError("Cannot relax the seeds", err)
+	}
+
+	// Intercepts the C key and toggles the comparison with the exact diagram
+	if inpututil.IsKeyJustPressed(ebiten.KeyC) {
+		g.comparing = !g.comparing
+	}
+
+	// an edit of the seeds rejected by the engine (e.g. invalid seeds) is reported without stopping the viewer
+	logError("Cannot edit the seeds", g.handleMouse())
+
 	if g.gameRunning {
+		// in kinetic mode the seeds move at every tick, and the whole diagram is computed again
+		if g.motion != nil {
+			if err := g.move(); err != nil {
+				return err
+			}
+		}
+
 		// compute the voronoi tessellation
-		return g.voronoi.Tessellate(g.hideIterations)
+		if err := g.voronoi.Tessellate(g.hideIterations || g.motion != nil); err != nil {
+			return err
+		}
+	}
+
+	// the moving seeds are reported once they stop
+	if g.voronoi.Done() && !g.reported && g.motion == nil {
+		g.report()
+	}
+
+	if g.relaxing && g.voronoi.Done() {
+		movement, err := g.relax()
+		g.relaxSteps++
+
+		// a failed step stops the relaxation, instead of failing again at every tick
+		logError("Cannot relax the seeds", err)
+		if err != nil || movement < g.relaxTolerance || g.relaxSteps >= g.relaxIterations {
+			if err == nil {
+				fmt.Println("Relaxation completed after", g.relaxSteps, "steps")
+			}
+			g.relaxing = false
+			g.pushHistory()
+		}
+	}
+
+	if g.triangulating && g.triangulation == nil {
+		g.triangulation = voronoi.Triangulate(g.voronoi.Diagram().Seeds)
+	}
+
+	if g.comparing && g.reference == nil {
+		return g.computeReference()
 	}
 	return nil
 }
 
+// handleMouse edits the seeds with the mouse: the left button adds a seed, or drags an existing one,
+// and the right button removes the nearest seed
+func (g *Canvas) handleMouse() error {
+
+	// the seeds within this distance from the cursor (in pixels of the canvas) can be dragged
+	const grabDistance = 5
+
+	x, y := ebiten.CursorPosition()
+	inside := x >= 0 && x < g.width && y >= 0 && y < g.height
+
+	switch {
+	case g.dragging >= 0 && ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft):
+		x = clamp(x, 0, g.width-1)
+		y = clamp(y, 0, g.height-1)
+		if g.dragX == x && g.dragY == y {
+			return nil
+		}
+		g.dragX, g.dragY = x, y
+		return g.moveSeed(g.dragging, x, y)
+
+	case g.dragging >= 0:
+		g.dragging = -1
+		g.pushHistory()
+
+	case inside && inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft):
+		seeds := g.voronoi.Diagram().Seeds
+		if i, distance := nearestSeed(seeds, x, y); i >= 0 && distance <= grabDistance {
+			g.dragging = i
+			g.dragX, g.dragY = seeds[i].X, seeds[i].Y
+			return nil
+		}
+		seed := voronoi.Point{
+			X: x,
+			Y: y,
+			Color: &voronoi.Color{
+				R: uint8(g.random.Intn(256)),
+				G: uint8(g.random.Intn(256)),
+				B: uint8(g.random.Intn(256)),
+				A: 255,
+			},
+		}
+		if g.motion != nil {
+			seed.Velocity = voronoi.RandomVelocity(g.speed, g.random)
+		}
+		return g.addSeed(seed)
+
+	case inside && inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight):
+		if i, _ := nearestSeed(g.voronoi.Diagram().Seeds, x, y); i >= 0 {
+			return g.removeSeed(i)
+		}
+	}
+
+	return nil
+}
+
+// addSeed adds a seed to the diagram.
+// The engines able to edit a single seed recompute only its cell (see seedEditor),
+// the other ones restart the tessellation
+func (g *Canvas) addSeed(seed voronoi.Point) error {
+
+	if editor, ok := g.seedEditor(); ok {
+		if err := editor.AddSeed(seed); err != nil {
+			return err
+		}
+		g.seedsEdited()
+		return nil
+	}
+	return g.editSeeds(append(g.voronoi.Diagram().Seeds, seed))
+}
+
+// removeSeed removes a seed (given its index) from the diagram.
+// The engines able to edit a single seed recompute only its cell (see seedEditor),
+// the other ones restart the tessellation
+func (g *Canvas) removeSeed(index int) error {
+
+	if editor, ok := g.seedEditor(); ok {
+		if err := editor.RemoveSeed(index); err != nil {
+			return err
+		}
+		g.seedsEdited()
+		return nil
+	}
+	seeds := g.voronoi.Diagram().Seeds
+	return g.editSeeds(append(seeds[:index], seeds[index+1:]...))
+}
+
+// moveSeed moves a seed (given its index) to the pixel (x, y).
+// The engines able to edit a single seed recompute only the cells around it (see seedEditor),
+// the other ones restart the tessellation
+func (g *Canvas) moveSeed(index int, x int, y int) error {
+
+	if editor, ok := g.seedEditor(); ok {
+		if err := editor.MoveSeed(index, x, y); err != nil {
+			return err
+		}
+		g.seedsEdited()
+		return nil
+	}
+	seeds := g.voronoi.Diagram().Seeds
+	seeds[index].X = x
+	seeds[index].Y = y
+	return g.editSeeds(seeds)
+}
+
+// seedEditor returns the engine as a voronoi.SeedEditor, if it can edit a single seed and the growth of the cells is not shown:
+// when the iterations are shown, the edits restart the tessellation so that the cells grow again
+// (except in kinetic mode, where the growth is never shown)
+func (g *Canvas) seedEditor() (voronoi.SeedEditor, bool) {
+
+	if !g.hideIterations && g.motion == nil {
+		return nil, false
+	}
+	editor, ok := g.voronoi.(voronoi.SeedEditor)
+	return editor, ok
+}
+
+// editSeeds replaces the seeds with the edited ones, and restarts the tessellation
+func (g *Canvas) editSeeds(seeds []voronoi.Point) error {
+
+	if err := g.voronoi.SetSeeds(seeds); err != nil {
+		return err
+	}
+	g.seedsEdited()
+	return nil
+}
+
+// seedsEdited discards everything computed for the previous seeds after an edit with the mouse,
+// and records the edit in the history, unless a seed is still being dragged
+func (g *Canvas) seedsEdited() {
+
+	g.seedsChanged()
+	g.relaxing = false
+
+	if g.dragging < 0 {
+		g.pushHistory()
+	}
+}
+
+// restoreSeeds shows again a set of seeds from the history, restarting the tessellation.
+// The random seed and the distribution they came from are restored and reported too
+func (g *Canvas) restoreSeeds(s Snapshot) error {
+
+	if err := g.voronoi.SetSeeds(s.Seeds); err != nil {
+		return err
+	}
+	g.seedsChanged()
+	g.relaxing = false
+	g.dragging = -1
+
+	if s.RandSeed != g.voronoi.RandSeed() || s.Generator.String() != g.voronoi.SeedGenerator().String() {
+		g.voronoi.SetRandSeed(s.RandSeed)
+		g.voronoi.SetSeedGenerator(s.Generator)
+		g.showRandSeed()
+	}
+	return nil
+}
+
+// pushHistory records the current seeds in the history, with the random seed and the distribution they came from
+func (g *Canvas) pushHistory() {
+	g.history.Push(Snapshot{Seeds: g.voronoi.Diagram().Seeds, RandSeed: g.voronoi.RandSeed(), Generator: g.voronoi.SeedGenerator()})
+}
+
+// nearestSeed returns the index of the seed nearest to the pixel (x, y) and its distance (-1 if there are no seeds)
+func nearestSeed(seeds []voronoi.Point, x int, y int) (int, float64) {
+
+	nearest := -1
+	distance := math.Inf(1)
+	for i, s := range seeds {
+		if d := math.Hypot(float64(s.X-x), float64(s.Y-y)); d < distance {
+			nearest = i
+			distance = d
+		}
+	}
+	return nearest, distance
+}
+
+// clamp limits a value between min and max
+func clamp(value int, min int, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+// newSeeds generates a new set of seeds from the given random seed, and restarts the tessellation.
+// The random seed is reported, so that the diagram can be recreated later
+// (the colors and velocities given to the seeds afterwards come from the same random seed)
+func (g *Canvas) newSeeds(randSeed int64) {
+
+	g.voronoi.SetRandSeed(randSeed)
+	g.voronoi.Init()
+	g.random = rand.New(rand.NewSource(randSeed))
+	g.seedsChanged()
+	g.dragging = -1
+	g.pushHistory()
+	g.showRandSeed()
+}
+
+// showRandSeed prints the random seed, and shows it in the title of the window with the distribution of the seeds
+func (g *Canvas) showRandSeed() {
+	fmt.Println("Random seed:", g.voronoi.RandSeed())
+	ebiten.SetWindowTitle(fmt.Sprintf("Voronoi Diagram (%s, seed %d)", g.voronoi.SeedGenerator(), g.voronoi.RandSeed()))
+}
+
+// nextSeedGenerator switches to the next distribution of the seeds, and regenerates them from the current random seed
+func (g *Canvas) nextSeedGenerator() {
+
+	generators := voronoi.SeedGenerators()
+	next := generators[0]
+	for i, generator := range generators {
+		if generator.String() == g.voronoi.SeedGenerator().String() {
+			next = generators[(i+1)%len(generators)]
+		}
+	}
+	g.voronoi.SetSeedGenerator(next)
+
+	fmt.Println("Seed distribution:", next)
+	g.newSeeds(g.voronoi.RandSeed())
+}
+
+// startMotion starts moving the seeds along their velocities
+func (g *Canvas) startMotion() {
+	g.motion = voronoi.NewMotion(g.width, g.height, g.border)
+	g.relaxing = false
+}
+
+// move moves the seeds along their velocities for the duration of a tick, restarting the tessellation.
+// If none of the seeds has a velocity (e.g. they have just been generated), they get random ones
+func (g *Canvas) move() error {
+
+	seeds := g.voronoi.Diagram().Seeds
+
+	still := true
+	for _, s := range seeds {
+		if s.Velocity != nil {
+			still = false
+			break
+		}
+	}
+	if still {
+		for i := range seeds {
+			seeds[i].Velocity = voronoi.RandomVelocity(g.speed, g.random)
+		}
+	}
+
+	// Update runs TPS times per second, or once per frame when the TPS follow the frame rate
+	dt := 1 / float64(ebiten.TPS())
+	if ebiten.TPS() == ebiten.SyncWithFPS {
+		dt = 0
+		if fps := ebiten.ActualFPS(); fps > 0 {
+			dt = 1 / fps
+		}
+	}
+
+	if err := g.voronoi.SetSeeds(g.motion.Step(seeds, dt)); err != nil {
+		return err
+	}
+	g.seedsChanged()
+	return nil
+}
+
+// seedsChanged discards everything computed for the previous seeds
+func (g *Canvas) seedsChanged() {
+	g.reference = nil
+	g.reported = false
+	g.triangulation = nil
+}
+
+// relax moves the seeds to the centroids of their cells, restarting the tessellation.
+// It returns the largest movement of the seeds
+func (g *Canvas) relax() (float64, error) {
+
+	movement, err := voronoi.RelaxStep(g.voronoi)
+	if err != nil {
+		return 0, err
+	}
+	g.seedsChanged()
+
+	// the automatic relaxation is recorded in the history only when it's complete
+	if !g.relaxing {
+		g.pushHistory()
+	}
+
+	fmt.Printf("Relaxation step: the seeds moved up to %.2f pixels\n", movement)
+	return movement, nil
+}
+
+// exportTriangulation writes the Delaunay triangulation of the current seeds to an OBJ file
+func (g *Canvas) exportTriangulation(path string) error {
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := voronoi.Triangulate(g.voronoi.Diagram().Seeds).WriteOBJ(f); err != nil {
+		return err
+	}
+
+	fmt.Println("Delaunay triangulation exported to", path)
+	return f.Close()
+}
+
+// saveState writes the state of the diagram to a JSON file, that can be loaded again with the -load-state flag
+func (g *Canvas) saveState(path string) error {
+
+	s, err := voronoi.NewState(g.voronoi)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := s.WriteJSON(f); err != nil {
+		return err
+	}
+
+	fmt.Println("Diagram state saved to", path)
+	return f.Close()
+}
+
+// logError reports the failure of an action of the user (e.g. writing a file), without stopping the viewer
+func logError(action string, err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, action+":", err)
+	}
+}
+
+// report prints the seeds whose cells ended up empty (e.g. the seeds dominated by larger ones in a power diagram)
+func (g *Canvas) report() {
+
+	g.reported = true
+
+	if empty := g.voronoi.Diagram().EmptyCells(); len(empty) > 0 {
+		fmt.Println("Seeds with an empty cell:", empty)
+	}
+}
+
+// computeReference computes the exact diagram for the current seeds, and reports the current accuracy
+func (g *Canvas) computeReference() error {
+
+	d := g.voronoi.Diagram()
+
+	opts := []voronoi.Option{
+		voronoi.WithSeeds(d.Seeds),
+		voronoi.WithMetric(d.Metric),
+		voronoi.WithWeighting(d.Weighting),
+	}
+	if d.Toroidal {
+		opts = append(opts, voronoi.WithToroidal())
+	}
+
+	bf, err := voronoi.NewBruteForce(g.width, g.height, 0, opts...)
+	if err != nil {
+		return err
+	}
+	bf.Init()
+	if err := bf.Tessellate(true); err != nil {
+		return err
+	}
+	g.reference = bf.Diagram()
+
+	c, err := voronoi.Compare(d, g.reference)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Comparison with the exact diagram:", c)
+
+	return nil
+}
+
 // Draw writes the computed frame as a byte sequence
 func (g *Canvas) Draw(screen *ebiten.Image) {
-	screen.WritePixels(g.voronoi.ToPixels())
+
+	var pixels []byte
+
+	if g.comparing && g.reference != nil {
+		c, err := voronoi.Compare(g.voronoi.Diagram(), g.reference)
+		if err == nil {
+			pixels = c.Overlay().Pix
+		}
+	}
+	if pixels == nil {
+		pixels = g.voronoi.ToPixels()
+	}
+
+	if g.triangulating && g.triangulation != nil {
+		for _, edge := range g.triangulation.Edges() {
+			a := g.triangulation.Vertices[edge[0]]
+			b := g.triangulation.Vertices[edge[1]]
+			g.drawLine(pixels, int(a.X), int(a.Y), int(b.X), int(b.Y))
+		}
+	}
+
+	screen.WritePixels(pixels)
+}
+
+// drawLine draws a white line between two pixels (Bresenham's algorithm)
+func (g *Canvas) drawLine(pixels []byte, x0 int, y0 int, x1 int, y1 int) {
+
+	dx := x1 - x0
+	if dx < 0 {
+		dx = -dx
+	}
+	dy := y0 - y1
+	if dy > 0 {
+		dy = -dy
+	}
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+
+	err := dx + dy
+	for {
+		pos := (y0*g.width + x0) * 4
+		pixels[pos] = 255
+		pixels[pos+1] = 255
+		pixels[pos+2] = 255
+		pixels[pos+3] = 255
+
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
 }
 
 // Layout returns the resolution of the canvas
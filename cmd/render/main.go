@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"voronoi/config"
+	"voronoi/voronoi"
+)
+
+// formats holds the extensions of the output files the render command can write
+var formats = map[string]bool{".png": true, ".svg": true, ".geojson": true, ".gif": true, ".apng": true, ".y4m": true}
+
+func main() {
+	if err := render(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// render computes a diagram without opening any window, and writes it to a PNG, SVG or GeoJSON file,
+// or records its growth as an animation (depending on the extension of the file).
+// It doesn't depend on Ebiten, so it builds and runs on machines without a display (e.g. build servers)
+func render(args []string) error {
+
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	output := fs.String("out", "voronoi.png", "path of the file to write: a PNG image, an SVG one if the extension is .svg, GeoJSON cells if it's .geojson, "+
+		"or the animation of the growth if it's .gif, .apng or .y4m (- writes the Y4M video to the standard output)")
+	every := fs.Int("every", 1, "the animations record a frame every this number of steps of the tessellation")
+	fps := fs.Int("fps", 25, "frame rate of the animations (at most 100 for the GIF ones, whose delays are in hundredths of a second)")
+	svg := voronoi.DefaultSVGOptions()
+	fs.Float64Var(&svg.StrokeWidth, "stroke-width", svg.StrokeWidth, "width of the outlines of the cells in the SVG image (0 for no outlines)")
+	fs.Float64Var(&svg.Tolerance, "tolerance", svg.Tolerance, "maximum distance (in pixels) between the simplified outlines of the SVG image and the pixels")
+	fs.Float64Var(&svg.SeedRadius, "seed-radius", svg.SeedRadius, "radius of the seeds in the SVG image (0 to hide them)")
+	geoTransform := fs.String("geo-transform", "", "affine transform a,b,c,d,e,f from the pixels to the GeoJSON coordinates: x'=a*x+b*y+c, y'=d*x+e*y+f")
+	geoBounds := fs.String("geo-bounds", "", "bounding box west,south,east,north the canvas is mapped to in the GeoJSON coordinates")
+
+	cfg, err := config.Parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if *every < 1 || *fps < 1 {
+		return errors.New("The frames must be recorded at least every step, at a positive frame rate")
+	}
+	extension := strings.ToLower(filepath.Ext(*output))
+	if _, supported := formats[extension]; !supported && *output != "-" {
+		return fmt.Errorf("Unknown extension %q of the output file: the supported ones are .png, .svg, .geojson, .gif, .apng and .y4m", extension)
+	}
+
+	geo := voronoi.GeoJSONOptions{Tolerance: svg.Tolerance}
+	if geo.Transform, err = parseTransform(*geoTransform, *geoBounds, cfg.Width, cfg.Height); err != nil {
+		return err
+	}
+
+	v, err := cfg.NewEngine()
+	if err != nil {
+		return err
+	}
+
+	v.Init()
+
+	// the standard output may be the video itself
+	messages := os.Stdout
+	f := os.Stdout
+	if *output == "-" {
+		messages = os.Stderr
+	} else {
+		if f, err = os.Create(*output); err != nil {
+			return err
+		}
+		defer f.Close()
+	}
+	fmt.Fprintln(messages, "Random seed:", v.RandSeed())
+
+	switch {
+	case *output == "-":
+		err = record(v, voronoi.NewY4MWriter(f, *fps), *every, messages)
+	case extension == ".gif":
+		err = record(v, voronoi.NewGIFWriter(f, v.Diagram().Seeds, *fps), *every, messages)
+	case extension == ".apng":
+		err = record(v, voronoi.NewAPNGWriter(f, *fps), *every, messages)
+	case extension == ".y4m":
+		err = record(v, voronoi.NewY4MWriter(f, *fps), *every, messages)
+	default:
+		err = draw(v, f, extension, svg, geo)
+	}
+	if err != nil || f == os.Stdout {
+		return err
+	}
+	return f.Close()
+}
+
+// record writes the animation of the growth of the diagram
+func record(v voronoi.Engine, a voronoi.AnimationWriter, every int, messages io.Writer) error {
+
+	frames, err := voronoi.RecordAnimation(v, a, every)
+	if err != nil {
+		return err
+	}
+	if err := a.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(messages, "Frames recorded:", frames)
+	return nil
+}
+
+// draw completes the tessellation, and writes the diagram in the format given by the extension of the file
+func draw(v voronoi.Engine, f io.Writer, extension string, svg voronoi.SVGOptions, geo voronoi.GeoJSONOptions) error {
+
+	for !v.Done() {
+		if err := v.Tessellate(true); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	switch extension {
+	case ".svg":
+		err = v.Diagram().WriteSVG(f, svg)
+	case ".geojson":
+		err = v.Diagram().WriteGeoJSON(f, geo)
+	case ".png":
+		err = png.Encode(f, v.Diagram().Image())
+	}
+	return err
+}
+
+// parseTransform parses the transform from the pixels to the GeoJSON coordinates,
+// given either as the coefficients of an affine transform or as a bounding box (nil if neither is set)
+func parseTransform(coefficients string, bounds string, width int, height int) (*voronoi.Affine, error) {
+
+	switch {
+	case coefficients != "" && bounds != "":
+		return nil, errors.New("The GeoJSON transform and bounds cannot be set together")
+	case coefficients != "":
+		c, err := parseNumbers(coefficients, 6)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid GeoJSON transform: %v", err)
+		}
+		return &voronoi.Affine{A: c[0], B: c[1], C: c[2], D: c[3], E: c[4], F: c[5]}, nil
+	case bounds != "":
+		b, err := parseNumbers(bounds, 4)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid GeoJSON bounds: %v", err)
+		}
+		t := voronoi.BoundsTransform(width, height, b[0], b[1], b[2], b[3])
+		return &t, nil
+	default:
+		return nil, nil
+	}
+}
+
+// parseNumbers parses a list of comma-separated numbers of the given length
+func parseNumbers(list string, length int) ([]float64, error) {
+
+	fields := strings.Split(list, ",")
+	if len(fields) != length {
+		return nil, fmt.Errorf("expected %d comma-separated numbers, found %d", length, len(fields))
+	}
+
+	numbers := []float64{}
+	for _, field := range fields {
+		n, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", field)
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, nil
+}
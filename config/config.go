@@ -0,0 +1,393 @@
+/*
+Package config holds the settings shared by the viewer and the render command,
+read from the command-line flags and from a JSON config file
+*/
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+
+	"voronoi/voronoi"
+)
+
+// Config holds the settings of the viewer and of the render command.
+// The same keys are used by the command-line flags and by the JSON config file
+type Config struct {
+
+	// size of the system window generated by Ebiten
+	WindowWidth  int `json:"window-width"`
+	WindowHeight int `json:"window-height"`
+
+	// resolution of the canvas shown in the window
+	Width  int `json:"width"`
+	Height int `json:"height"`
+
+	// if true, only the final result of the voronoi tessellation is shown
+	HideIterations bool `json:"hide-iterations"`
+
+	// number of randomly generated seeds for the voronoi diagram
+	Seeds int `json:"seeds"`
+
+	// random seed of the first set of seeds, to recreate a diagram (nil for a random one)
+	Seed *int64 `json:"seed"`
+
+	// distribution of the random seeds
+	Distribution string `json:"distribution"`
+
+	// CSV or JSON file the seeds are read from, in place of the random ones,
+	// and whether its coordinates are rescaled to fit the canvas
+	SeedsFile string `json:"seeds-file"`
+	FitSeeds  bool   `json:"fit-seeds"`
+
+	// state document the diagram is loaded from (its seeds and settings)
+	LoadState string `json:"load-state"`
+
+	// backend used to compute the voronoi diagram, and its settings
+	Algorithm     string  `json:"algorithm"`
+	Metric        string  `json:"metric"`
+	P             float64 `json:"p"`
+	Weighting     string  `json:"weighting"`
+	Toroidal      bool    `json:"toroidal"`
+	JFACorrection int     `json:"jfa-correction"`
+	Workers       int     `json:"workers"`
+
+	// the Lloyd relaxation stops after this number of steps,
+	// or when no seed moves more than the tolerance (in pixels)
+	RelaxIterations int     `json:"relax-iterations"`
+	RelaxTolerance  float64 `json:"relax-tolerance"`
+
+	// file the Delaunay triangulation is exported to
+	TriangulationFile string `json:"triangulation-file"`
+
+	// file the state of the diagram is saved to
+	StateFile string `json:"state-file"`
+
+	// number of sets of seeds kept in the history, to undo their changes
+	HistorySize int `json:"history-size"`
+
+	// kinetic mode: the seeds move along their velocities (read from the seeds file, or random with the given speed),
+	// bouncing or wrapping at the borders of the canvas
+	Kinetic bool    `json:"kinetic"`
+	Speed   float64 `json:"speed"`
+	Border  string  `json:"border"`
+
+	seeds []voronoi.Point // seeds read from the seeds file or the state document (nil if not set)
+}
+
+// defaultConfig returns the settings used when neither the flags nor the config file set them
+func defaultConfig() Config {
+	return Config{
+		WindowWidth:       1000,
+		WindowHeight:      1000,
+		Width:             500,
+		Height:            500,
+		HideIterations:    false,
+		Seeds:             30,
+		Distribution:      "uniform",
+		Algorithm:         string(voronoi.AlgorithmWavefront),
+		Metric:            "euclidean",
+		P:                 3,
+		Weighting:         "none",
+		Toroidal:          false,
+		JFACorrection:     0,
+		Workers:           0,
+		RelaxIterations:   100,
+		RelaxTolerance:    1.0,
+		TriangulationFile: "delaunay.obj",
+		StateFile:         "voronoi-state.json",
+		HistorySize:       100,
+		Kinetic:           false,
+		Speed:             40,
+		Border:            "bounce",
+	}
+}
+
+/*
+Parse parses the command-line arguments into the settings of the application
+
+The flags are registered in the given flag set (along with the ones already there, e.g. the ones of a command).
+If the -config flag points to a JSON file, the file is loaded first,
+and the flags explicitly set on the command line override its values.
+A state document (-load-state) overrides the settings of the config file, and the explicit flags override it in turn
+*/
+func Parse(fs *flag.FlagSet, args []string) (*Config, error) {
+
+	c := defaultConfig()
+	cfg := &c
+
+	configFile := fs.String("config", "", "path of a JSON config file, with the same keys of the flags (the flags override it)")
+	fs.IntVar(&cfg.WindowWidth, "window-width", cfg.WindowWidth, "width of the window")
+	fs.IntVar(&cfg.WindowHeight, "window-height", cfg.WindowHeight, "height of the window")
+	fs.IntVar(&cfg.Width, "width", cfg.Width, "horizontal resolution of the canvas (in pixels)")
+	fs.IntVar(&cfg.Height, "height", cfg.Height, "vertical resolution of the canvas (in pixels)")
+	fs.BoolVar(&cfg.HideIterations, "hide-iterations", cfg.HideIterations, "show only the final result of the tessellation")
+	fs.IntVar(&cfg.Seeds, "seeds", cfg.Seeds, "number of randomly generated seeds")
+	fs.Var(optionalInt64{&cfg.Seed}, "seed", "random seed of the first set of seeds, to recreate a diagram (a random one if not set)")
+	fs.StringVar(&cfg.Distribution, "distribution", cfg.Distribution, "distribution of the random seeds: uniform, poisson, jittered, hex, halton or clusters")
+	fs.StringVar(&cfg.SeedsFile, "seeds-file", cfg.SeedsFile, "CSV or JSON file the seeds are read from, in place of the random ones")
+	fs.BoolVar(&cfg.FitSeeds, "fit-seeds", cfg.FitSeeds, "rescale the coordinates of the seeds file to fit the canvas")
+	fs.StringVar(&cfg.LoadState, "load-state", cfg.LoadState, "state document the diagram is loaded from (the flags override its settings)")
+	fs.StringVar(&cfg.Algorithm, "algorithm", cfg.Algorithm, "backend used to compute the diagram: wavefront, fortune, bruteforce, jfa or edt")
+	fs.StringVar(&cfg.Metric, "metric", cfg.Metric, "metric used to measure the distances: euclidean, manhattan, chebyshev or minkowski")
+	fs.Float64Var(&cfg.P, "p", cfg.P, "order of the minkowski metric (at least 1)")
+	fs.StringVar(&cfg.Weighting, "weighting", cfg.Weighting, "way the random weights of the seeds affect the distances: none, multiplicative or power")
+	fs.BoolVar(&cfg.Toroidal, "toroidal", cfg.Toroidal, "wrap the canvas around both axes, producing a tileable diagram")
+	fs.IntVar(&cfg.JFACorrection, "jfa-correction", cfg.JFACorrection, "correction passes of the jfa algorithm (0, 1 or 2)")
+	fs.IntVar(&cfg.Workers, "workers", cfg.Workers, "number of goroutines computing the wavefront and jfa diagrams in parallel (0 for the number of CPUs)")
+	fs.IntVar(&cfg.RelaxIterations, "relax-iterations", cfg.RelaxIterations, "maximum number of steps of the Lloyd relaxation")
+	fs.Float64Var(&cfg.RelaxTolerance, "relax-tolerance", cfg.RelaxTolerance, "the Lloyd relaxation stops when no seed moves more than this (in pixels)")
+	fs.StringVar(&cfg.TriangulationFile, "triangulation-file", cfg.TriangulationFile, "file the Delaunay triangulation is exported to")
+	fs.StringVar(&cfg.StateFile, "state-file", cfg.StateFile, "file the state of the diagram is saved to")
+	fs.IntVar(&cfg.HistorySize, "history-size", cfg.HistorySize, "number of sets of seeds kept in the history, to undo their changes")
+	fs.BoolVar(&cfg.Kinetic, "kinetic", cfg.Kinetic, "start with the seeds moving along their velocities")
+	fs.Float64Var(&cfg.Speed, "speed", cfg.Speed, "speed of the random velocities of the moving seeds (pixels per second)")
+	fs.StringVar(&cfg.Border, "border", cfg.Border, "behavior of the moving seeds at the borders of the canvas: bounce or wrap")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("Unexpected arguments: %v", fs.Args())
+	}
+
+	// the values of the flags explicitly set are applied again on top of the files
+	explicit := map[string]string{}
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = f.Value.String()
+	})
+	override := func() error {
+		for name, value := range explicit {
+			if err := fs.Set(name, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if *configFile != "" {
+		*cfg = defaultConfig()
+		if err := cfg.load(*configFile); err != nil {
+			return nil, err
+		}
+		if err := override(); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.LoadState != "" {
+		if err := cfg.loadState(cfg.LoadState); err != nil {
+			return nil, err
+		}
+		if err := override(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.loadSeeds(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// load reads the settings from a JSON config file: the keys missing in the file keep their current values
+func (c *Config) load(path string) error {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	decoder := json.NewDecoder(f)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("Invalid config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// loadState reads a state document, and applies its seeds and settings
+func (c *Config) loadState(path string) error {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s, err := voronoi.ReadState(f)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	c.Width = s.Width
+	c.Height = s.Height
+	c.Seeds = len(s.Seeds)
+	c.Seed = &s.RandSeed
+	c.Algorithm = string(s.Algorithm)
+	c.Metric = s.Metric
+	if s.Metric == "minkowski" {
+		c.P = s.P
+	}
+	c.Weighting = s.Weighting
+	c.Toroidal = s.Toroidal
+	c.JFACorrection = s.JFACorrection
+	c.seeds = s.Points()
+
+	return nil
+}
+
+// loadSeeds reads the seeds file, if any, and places its seeds on the canvas
+func (c *Config) loadSeeds() error {
+
+	if c.SeedsFile == "" {
+		return nil
+	}
+
+	imported, err := voronoi.ReadSeedsFile(c.SeedsFile)
+	if err != nil {
+		return err
+	}
+	c.seeds, err = voronoi.PlaceSeeds(imported, c.Width, c.Height, c.FitSeeds)
+	if err != nil {
+		return fmt.Errorf("%s: %v", c.SeedsFile, err)
+	}
+	return nil
+}
+
+// validate checks the settings, and their combinations
+func (c *Config) validate() error {
+
+	if c.WindowWidth <= 0 || c.WindowHeight <= 0 {
+		return errors.New("The size of the window must be positive")
+	}
+	if c.Width <= 0 || c.Height <= 0 {
+		return errors.New("The resolution of the canvas must be positive")
+	}
+	if c.Seeds < 0 || c.Seeds > c.Width*c.Height {
+		return fmt.Errorf("The number of seeds must be between 0 and the pixels of the canvas (%d)", c.Width*c.Height)
+	}
+	if c.RelaxIterations < 0 || c.RelaxTolerance < 0 {
+		return errors.New("The relax iterations and tolerance cannot be negative")
+	}
+	if c.Workers < 0 {
+		return errors.New("The number of workers cannot be negative")
+	}
+	if c.HistorySize < 1 {
+		return errors.New("The history must hold at least a set of seeds")
+	}
+	if c.Speed < 0 || math.IsInf(c.Speed, 0) || math.IsNaN(c.Speed) {
+		return errors.New("The speed of the seeds must be finite and not negative")
+	}
+	if c.TriangulationFile == "" || c.StateFile == "" {
+		return errors.New("The triangulation and state files cannot be empty")
+	}
+	if c.SeedsFile != "" && c.LoadState != "" {
+		return errors.New("The seeds file and the state document cannot be loaded together")
+	}
+
+	if _, err := voronoi.ParseSeedGenerator(c.Distribution); err != nil {
+		return err
+	}
+	if _, err := voronoi.ParseBorder(c.Border); err != nil {
+		return err
+	}
+	algorithm, err := voronoi.ParseAlgorithm(c.Algorithm)
+	if err != nil {
+		return err
+	}
+	metric, err := voronoi.ParseMetric(c.Metric, c.P)
+	if err != nil {
+		return err
+	}
+	weighting, err := voronoi.ParseWeighting(c.Weighting)
+	if err != nil {
+		return err
+	}
+
+	_, euclidean := metric.(voronoi.Euclidean)
+	switch {
+	case c.JFACorrection < 0 || c.JFACorrection > 2:
+		return errors.New("The jfa correction passes must be between 0 and 2")
+	case c.JFACorrection > 0 && algorithm != voronoi.AlgorithmJFA:
+		return fmt.Errorf("The jfa correction passes require the %s algorithm, not %s", voronoi.AlgorithmJFA, algorithm)
+	case algorithm == voronoi.AlgorithmFortune && !euclidean:
+		return fmt.Errorf("The %s algorithm supports only the euclidean metric, not %s", algorithm, metric)
+	case algorithm == voronoi.AlgorithmFortune && weighting != voronoi.Unweighted:
+		return fmt.Errorf("The %s algorithm doesn't support the %s weighting", algorithm, weighting)
+	case algorithm == voronoi.AlgorithmEDT && weighting == voronoi.Multiplicative:
+		return fmt.Errorf("The %s algorithm doesn't support the %s weighting", algorithm, weighting)
+	case weighting == voronoi.Power && !euclidean:
+		return fmt.Errorf("The %s weighting supports only the euclidean metric, not %s", weighting, metric)
+	}
+
+	return nil
+}
+
+// NewEngine creates the voronoi engine with the backend and the options of the config (already validated)
+func (c *Config) NewEngine() (voronoi.Engine, error) {
+	algorithm, _ := voronoi.ParseAlgorithm(c.Algorithm)
+	return voronoi.New(algorithm, c.Width, c.Height, c.Seeds, c.options()...)
+}
+
+// MotionBorder returns the behavior of the moving seeds at the borders of the canvas (the config is already validated)
+func (c *Config) MotionBorder() voronoi.Border {
+	border, _ := voronoi.ParseBorder(c.Border)
+	return border
+}
+
+// options returns the options of the voronoi engine (the config is already validated)
+func (c *Config) options() []voronoi.Option {
+
+	metric, _ := voronoi.ParseMetric(c.Metric, c.P)
+	weighting, _ := voronoi.ParseWeighting(c.Weighting)
+	generator, _ := voronoi.ParseSeedGenerator(c.Distribution)
+
+	opts := []voronoi.Option{
+		voronoi.WithMetric(metric),
+		voronoi.WithWeighting(weighting),
+		voronoi.WithSeedGenerator(generator),
+		voronoi.WithJFACorrection(c.JFACorrection),
+		voronoi.WithWorkers(c.Workers),
+	}
+	if c.Toroidal {
+		opts = append(opts, voronoi.WithToroidal())
+	}
+	if c.Seed != nil {
+		opts = append(opts, voronoi.WithRandSeed(*c.Seed))
+	}
+	if c.seeds != nil {
+		opts = append(opts, voronoi.WithSeeds(c.seeds))
+	}
+	return opts
+}
+
+// optionalInt64 is a flag setting a number that may be left unset (nil), so that any number can be set explicitly
+type optionalInt64 struct {
+	value **int64
+}
+
+func (o optionalInt64) String() string {
+	if o.value == nil || *o.value == nil {
+		return ""
+	}
+	return strconv.FormatInt(**o.value, 10)
+}
+
+func (o optionalInt64) Set(s string) error {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*o.value = &n
+	return nil
+}
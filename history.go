@@ -0,0 +1,95 @@
+package main
+
+import (
+	"voronoi/voronoi"
+)
+
+// History is a bounded list of the sets of seeds shown by the viewer, to undo and redo their changes
+type History struct {
+	entries []Snapshot
+	current int // index of the entry currently shown (-1 if the history is empty)
+	size    int // maximum number of entries: the oldest ones are dropped
+}
+
+// Snapshot is a set of seeds in the history, with the random seed and the distribution of the seeds generated last
+type Snapshot struct {
+	Seeds     []voronoi.Point
+	RandSeed  int64
+	Generator voronoi.SeedGenerator
+}
+
+// NewHistory creates an empty history, holding up to size sets of seeds
+func NewHistory(size int) *History {
+	return &History{
+		entries: []Snapshot{},
+		current: -1,
+		size:    size,
+	}
+}
+
+// Push records a new set of seeds after the current one, dropping the sets that could be redone.
+// A set equal to the current one (with the same random seed and distribution) is not recorded again
+func (h *History) Push(s Snapshot) {
+
+	if h.current >= 0 && sameSnapshots(h.entries[h.current], s) {
+		return
+	}
+
+	h.entries = append(h.entries[:h.current+1], s.copy())
+	if len(h.entries) > h.size {
+		h.entries = h.entries[len(h.entries)-h.size:]
+	}
+	h.current = len(h.entries) - 1
+}
+
+// Undo moves back to the previous set of seeds, and returns it (false if there is none)
+func (h *History) Undo() (Snapshot, bool) {
+
+	if h.current <= 0 {
+		return Snapshot{}, false
+	}
+	h.current--
+	return h.entries[h.current].copy(), true
+}
+
+// Redo moves forward to the set of seeds undone last, and returns it (false if there is none)
+func (h *History) Redo() (Snapshot, bool) {
+
+	if h.current+1 >= len(h.entries) {
+		return Snapshot{}, false
+	}
+	h.current++
+	return h.entries[h.current].copy(), true
+}
+
+// copy returns a snapshot with its own list of seeds
+func (s Snapshot) copy() Snapshot {
+	s.Seeds = append([]voronoi.Point{}, s.Seeds...)
+	return s
+}
+
+// sameSnapshots reports whether two snapshots have the same seeds, random seed and distribution
+func sameSnapshots(a Snapshot, b Snapshot) bool {
+	return a.RandSeed == b.RandSeed && a.Generator.String() == b.Generator.String() && sameSeeds(a.Seeds, b.Seeds)
+}
+
+// sameSeeds reports whether two sets of seeds are the same (positions, colors, weights, labels and velocities)
+func sameSeeds(a []voronoi.Point, b []voronoi.Point) bool {
+
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].X != b[i].X || a[i].Y != b[i].Y ||
+			a[i].Weight != b[i].Weight || a[i].Radius != b[i].Radius || a[i].Label != b[i].Label {
+			return false
+		}
+		if (a[i].Color == nil) != (b[i].Color == nil) || (a[i].Color != nil && *a[i].Color != *b[i].Color) {
+			return false
+		}
+		if (a[i].Velocity == nil) != (b[i].Velocity == nil) || (a[i].Velocity != nil && *a[i].Velocity != *b[i].Velocity) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+
+	"voronoi/voronoi"
+)
+
+// snapshot returns a snapshot with a single seed at (x, 0)
+func snapshot(x int) Snapshot {
+	return Snapshot{Seeds: []voronoi.Point{{X: x}}, RandSeed: 1, Generator: voronoi.Uniform{}}
+}
+
+// position returns the position of the single seed of a snapshot (-1 if there is none)
+func position(s Snapshot, ok bool) int {
+	if !ok {
+		return -1
+	}
+	return s.Seeds[0].X
+}
+
+// TestHistory checks the undo and redo of the seeds: the oldest sets are dropped beyond the size of the history,
+// a set equal to the current one is not recorded, and a new set drops the ones that could be redone
+func TestHistory(t *testing.T) {
+
+	h := NewHistory(3)
+	if _, ok := h.Undo(); ok {
+		t.Fatal("undo of an empty history")
+	}
+
+	for x := 1; x <= 5; x++ {
+		h.Push(snapshot(x))
+	}
+	h.Push(snapshot(5))
+
+	// only 3, 4 and 5 are kept
+	for _, expected := range []int{4, 3, -1} {
+		if x := position(h.Undo()); x != expected {
+			t.Fatalf("undo: %d instead of %d", x, expected)
+		}
+	}
+	for _, expected := range []int{4, 5, -1} {
+		if x := position(h.Redo()); x != expected {
+			t.Fatalf("redo: %d instead of %d", x, expected)
+		}
+	}
+
+	// a new set after an undo drops the undone one
+	h.Undo()
+	h.Push(snapshot(9))
+	if x := position(h.Redo()); x != -1 {
+		t.Fatalf("redo of a dropped set: %d", x)
+	}
+	if x := position(h.Undo()); x != 4 {
+		t.Fatalf("undo: %d instead of 4", x)
+	}
+
+	// the same seeds from another random seed are a new set
+	other := snapshot(4)
+	other.RandSeed = 2
+	h.Push(other)
+	if s, ok := h.Undo(); !ok || s.RandSeed != 1 || s.Seeds[0].X != 4 {
+		t.Fatalf("undo: %+v instead of the seeds of the first random seed", s)
+	}
+
+	// the returned seeds are copies
+	s, _ := h.Redo()
+	s.Seeds[0].X = 100
+	h.Undo()
+	if x := position(h.Redo()); x != 4 {
+		t.Fatalf("the history changed with the returned seeds: %d", x)
+	}
+}
@@ -1,46 +1,31 @@
 package main
 
 import (
-	ebiten "github.com/hajimehoshi/ebiten/v2"
-)
-
-const (
-	// size of the system window generated by Ebiten
-	windowSizeWidth  = 1000
-	windowSizeHeight = 1000
+	"flag"
+	"fmt"
+	"os"
 
-	// resolution of the canvas shown in the window
-	windowResolutionHorizontal = 500
-	windowResolutionVertical   = 500
+	"voronoi/config"
 
-	// if true, this flag show only the final result of the voronoi tessellation
-	hideIterations = false
-
-	// number of randomly generated seeds for the voronoi diagram
-	numSeeds = 30
+	ebiten "github.com/hajimehoshi/ebiten/v2"
 )
 
 func main() {
 
-	ebiten.SetWindowTitle("Voronoi Diagram")
+	cfg, cErr := config.Parse(flag.CommandLine, os.Args[1:])
+	if cErr != nil {
+		fmt.Fprintln(os.Stderr, cErr)
+		os.Exit(2)
+	}
 
-	ebiten.SetWindowSize(windowSizeWidth, windowSizeHeight)
+	ebiten.SetWindowSize(cfg.WindowWidth, cfg.WindowHeight)
 
-	voronoi, vErr := NewVoronoi(
-		windowResolutionHorizontal,
-		windowResolutionVertical,
-		numSeeds,
-	)
+	v, vErr := cfg.NewEngine()
 	if vErr != nil {
 		panic(vErr)
 	}
 
-	g, gErr := NewCanvas(
-		windowResolutionHorizontal,
-		windowResolutionVertical,
-		hideIterations,
-		voronoi,
-	)
+	g, gErr := NewCanvas(cfg, v)
 	if gErr != nil {
 		panic(gErr)
 	}
@@ -0,0 +1,282 @@
+package voronoi
+
+import (
+	"bufio"
+	"compress/lzw"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"math"
+)
+
+// AnimationWriter encodes the frames of an animation
+type AnimationWriter interface {
+
+	// WriteFrame adds a frame to the animation: all the frames have the same size
+	WriteFrame(frame *image.RGBA) error
+
+	// Close completes the animation. It doesn't close the underlying writer
+	Close() error
+}
+
+/*
+RecordAnimation records the growth of the diagram: the tessellation is computed one step at a time
+(as the viewer does when the iterations are shown), and the image of the diagram is added to the animation
+at the beginning and after every Nth step, until the tessellation is complete (the final diagram is always recorded).
+
+The engine must be initialized. The animation writer is not closed.
+It returns the number of recorded frames
+*/
+func RecordAnimation(e Engine, a AnimationWriter, every int) (int, error) {
+
+	if every < 1 {
+		return 0, errors.New("The frames must be recorded at least every step")
+	}
+
+	frames := 0
+	for step := 0; ; step++ {
+		done := e.Done()
+
+		if step%every == 0 || done {
+			if err := a.WriteFrame(e.Diagram().Image()); err != nil {
+				return frames, err
+			}
+			frames++
+		}
+		if done {
+			return frames, nil
+		}
+
+		if err := e.Tessellate(false); err != nil {
+			return frames, err
+		}
+	}
+}
+
+// gifWriter encodes an animated GIF, whose palette is made of the colors of the seeds
+type gifWriter struct {
+	w       *bufio.Writer
+	delay   int // delay between the frames, in hundredths of a second
+	palette color.Palette
+	indexes map[color.RGBA]uint8 // index in the palette of each color found in the frames
+
+	size   image.Point // size of the frames, taken from the first one
+	frames int
+	pixels []byte // buffer of the palette indexes of a frame
+
+	err error // first error writing the animation: nothing else is written after it
+}
+
+/*
+NewGIFWriter creates an animation writer encoding an animated GIF, looping forever at the given frame rate.
+The delays of a GIF are in hundredths of a second, and at least 1: the frame rate is 100 at most.
+The palette is made of black (the unassigned pixels and the seeds) and the colors of the seeds, shown opaque as in the viewer:
+with more than 255 colors, an evenly spaced selection of them is used, and the other colors are approximated.
+The frames are compressed and written as soon as they are added
+*/
+func NewGIFWriter(w io.Writer, seeds []Point, fps int) AnimationWriter {
+
+	palette := color.Palette{color.RGBA{A: 255}}
+	found := map[color.RGBA]bool{{A: 255}: true}
+	colors := []color.Color{}
+	for _, s := range seeds {
+		if s.Color == nil {
+			continue
+		}
+		c := color.RGBA{R: s.Color.R, G: s.Color.G, B: s.Color.B, A: 255}
+		if !found[c] {
+			found[c] = true
+			colors = append(colors, c)
+		}
+	}
+
+	// a GIF palette holds up to 256 colors
+	for i := 0; i < 255 && i < len(colors); i++ {
+		palette = append(palette, colors[i*len(colors)/int(math.Min(255, float64(len(colors))))])
+	}
+
+	return &gifWriter{
+		w:       bufio.NewWriter(w),
+		delay:   int(math.Max(1, math.Round(100/float64(fps)))),
+		palette: palette,
+		indexes: map[color.RGBA]uint8{},
+	}
+}
+
+// WriteFrame converts a frame to the palette of the seeds, and writes it
+func (g *gifWriter) WriteFrame(frame *image.RGBA) error {
+
+	if g.err != nil {
+		return g.err
+	}
+
+	size := frame.Bounds().Size()
+	if g.frames == 0 {
+		if size.X > math.MaxUint16 || size.Y > math.MaxUint16 {
+			return errors.New("The frames of a GIF animation cannot be larger than 65535 pixels")
+		}
+		g.size = size
+		g.pixels = make([]byte, size.X*size.Y)
+		g.writeHeader()
+		if g.err != nil {
+			return g.err
+		}
+	} else if size != g.size {
+		return errors.New("All the frames of the animation must have the same size")
+	}
+
+	for row := 0; row < size.Y; row++ {
+		for x := 0; x < size.X; x++ {
+			p := frame.Pix[row*frame.Stride+x*4:]
+			c := color.RGBA{R: p[0], G: p[1], B: p[2], A: 255}
+
+			// the same colors are found over and over in the frames, so the nearest one in the palette is looked up only once
+			index, found := g.indexes[c]
+			if !found {
+				index = uint8(g.palette.Index(c))
+				g.indexes[c] = index
+			}
+			g.pixels[row*size.X+x] = index
+		}
+	}
+
+	// graphic control extension (the delay of the frame, no disposal and no transparency)
+	g.write(0x21, 0xf9, 0x04, 0x00)
+	g.writeUint16(g.delay)
+	g.write(0x00, 0x00)
+
+	// image descriptor, covering the whole canvas with the global palette
+	g.write(0x2c)
+	g.writeUint16(0)
+	g.writeUint16(0)
+	g.writeUint16(size.X)
+	g.writeUint16(size.Y)
+	g.write(0x00)
+
+	// image data, compressed with LZW in blocks of up to 255 bytes
+	codeSize := g.paletteBits()
+	if codeSize < 2 {
+		codeSize = 2
+	}
+	g.write(byte(codeSize))
+	if g.err != nil {
+		return g.err
+	}
+	blocks := &gifBlocks{g: g}
+	z := lzw.NewWriter(blocks, lzw.LSB, codeSize)
+	if _, err := z.Write(g.pixels); err != nil {
+		return err
+	}
+	if err := z.Close(); err != nil {
+		return err
+	}
+	if err := blocks.close(); err != nil {
+		return err
+	}
+
+	g.frames++
+	return nil
+}
+
+// Close completes the animation
+func (g *gifWriter) Close() error {
+
+	if g.frames == 0 {
+		return errors.New("The animation has no frames")
+	}
+	g.write(0x3b)
+	if g.err != nil {
+		return g.err
+	}
+	return g.w.Flush()
+}
+
+// writeHeader writes the header of the GIF: the size of the canvas, the global palette, and the loop forever
+func (g *gifWriter) writeHeader() {
+
+	bits := g.paletteBits()
+
+	g.write([]byte("GIF89a")...)
+	g.writeUint16(g.size.X)
+	g.writeUint16(g.size.Y)
+	g.write(0x80|byte(bits-1)<<4|byte(bits-1), 0x00, 0x00)
+
+	// the global palette, padded with black to a power of 2
+	for i := 0; i < 1<<bits; i++ {
+		c := color.RGBA{}
+		if i < len(g.palette) {
+			c = g.palette[i].(color.RGBA)
+		}
+		g.write(c.R, c.G, c.B)
+	}
+
+	// application extension looping the animation forever
+	g.write(0x21, 0xff, 0x0b)
+	g.write([]byte("NETSCAPE2.0")...)
+	g.write(0x03, 0x01, 0x00, 0x00, 0x00)
+}
+
+// paletteBits returns the number of bits of the indexes of the palette (at least 1)
+func (g *gifWriter) paletteBits() int {
+	bits := 1
+	for 1<<bits < len(g.palette) {
+		bits++
+	}
+	return bits
+}
+
+// write writes some bytes, unless a previous write failed: the first error is kept, and reported by the callers
+func (g *gifWriter) write(data ...byte) {
+	if g.err == nil {
+		_, g.err = g.w.Write(data)
+	}
+}
+
+// writeUint16 writes a little endian 16 bits number
+func (g *gifWriter) writeUint16(n int) {
+	g.write(byte(n), byte(n>>8))
+}
+
+// gifBlocks splits the compressed data of a GIF image in blocks of up to 255 bytes, each one preceded by its length
+type gifBlocks struct {
+	g      *gifWriter
+	buffer [255]byte
+	length int
+}
+
+func (b *gifBlocks) Write(data []byte) (int, error) {
+
+	for i, c := range data {
+		b.buffer[b.length] = c
+		b.length++
+		if b.length == len(b.buffer) {
+			if err := b.flush(); err != nil {
+				return i, err
+			}
+		}
+	}
+	return len(data), nil
+}
+
+// flush writes the pending block
+func (b *gifBlocks) flush() error {
+
+	if b.length == 0 {
+		return nil
+	}
+	b.g.write(byte(b.length))
+	b.g.write(b.buffer[:b.length]...)
+	b.length = 0
+	return b.g.err
+}
+
+// close writes the pending block and the empty block ending the data
+func (b *gifBlocks) close() error {
+
+	if err := b.flush(); err != nil {
+		return err
+	}
+	b.g.write(0x00)
+	return b.g.err
+}
@@ -0,0 +1,289 @@
+package voronoi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+)
+
+// frameList is an animation writer keeping the recorded frames
+type frameList struct {
+	frames []*image.RGBA
+}
+
+func (l *frameList) WriteFrame(frame *image.RGBA) error {
+	l.frames = append(l.frames, frame)
+	return nil
+}
+
+func (l *frameList) Close() error {
+	return nil
+}
+
+// growth records the growth of a small diagram, returning its seeds and its frames
+func growth(t *testing.T) ([]Point, []*image.RGBA) {
+
+	v, err := NewVoronoi(40, 30, 8, WithRandSeed(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Init()
+
+	l := &frameList{}
+	frames, err := RecordAnimation(v, l, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frames != len(l.frames) || frames < 3 {
+		t.Fatalf("%d frames recorded, %d written", frames, len(l.frames))
+	}
+	if !v.Done() {
+		t.Fatal("The recording stopped before the end of the tessellation")
+	}
+	return v.Diagram().Seeds, l.frames
+}
+
+// writeAnimation writes the frames with an animation writer
+func writeAnimation(a AnimationWriter, frames []*image.RGBA) error {
+	for _, frame := range frames {
+		if err := a.WriteFrame(frame); err != nil {
+			return err
+		}
+	}
+	return a.Close()
+}
+
+// samePixels returns a description of the first pixel of an image whose color is not the one of the frame
+// (an empty string if there are none)
+func samePixels(img image.Image, frame *image.RGBA) string {
+
+	for y := 0; y < frame.Rect.Dy(); y++ {
+		for x := 0; x < frame.Rect.Dx(); x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			expected := frame.RGBAAt(x, y)
+			if uint8(r>>8) != expected.R || uint8(g>>8) != expected.G || uint8(b>>8) != expected.B {
+				return fmt.Sprintf("(%d, %d) is (%d, %d, %d) instead of %v", x, y, r>>8, g>>8, b>>8, expected)
+			}
+		}
+	}
+	return ""
+}
+
+// TestGIFRoundTrip decodes the animated GIF of a growth: the frames, their delays and their pixels
+// (the colors of the seeds, all in the palette) must be the recorded ones
+func TestGIFRoundTrip(t *testing.T) {
+
+	seeds, frames := growth(t)
+
+	for _, c := range []struct{ fps, delay int }{{25, 4}, {100, 1}, {1000, 1}} {
+		var buf bytes.Buffer
+		if err := writeAnimation(NewGIFWriter(&buf, seeds, c.fps), frames); err != nil {
+			t.Fatal(err)
+		}
+
+		g, err := gif.DecodeAll(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(g.Image) != len(frames) || g.LoopCount != 0 {
+			t.Fatalf("%d frames looping %d times instead of %d frames looping forever", len(g.Image), g.LoopCount, len(frames))
+		}
+		for i, img := range g.Image {
+			if g.Delay[i] != c.delay {
+				t.Fatalf("%d fps: frame %d has delay %d instead of %d", c.fps, i, g.Delay[i], c.delay)
+			}
+			if diff := samePixels(img, frames[i]); diff != "" {
+				t.Fatalf("frame %d: %s", i, diff)
+			}
+		}
+	}
+}
+
+// TestAPNGRoundTrip decodes an animated PNG: the default image must be the first frame,
+// and every frame (decoded as a PNG on its own) must have the recorded pixels and the delay of the frame rate
+func TestAPNGRoundTrip(t *testing.T) {
+
+	_, frames := growth(t)
+
+	var buf bytes.Buffer
+	if err := writeAnimation(NewAPNGWriter(&buf, 20), frames); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := samePixels(img, frames[0]); diff != "" {
+		t.Fatalf("default image: %s", diff)
+	}
+
+	chunks, err := readChunks(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunks[0].name != "IHDR" || chunks[1].name != "acTL" || chunks[len(chunks)-1].name != "IEND" {
+		t.Fatal("The animation doesn't start with the IHDR and acTL chunks, or doesn't end with IEND")
+	}
+	if n := binary.BigEndian.Uint32(chunks[1].data); int(n) != len(frames) {
+		t.Fatalf("acTL declares %d frames instead of %d", n, len(frames))
+	}
+
+	decoded := 0
+	for _, c := range chunks {
+		switch c.name {
+		case "fcTL":
+			if delay := [2]uint16{binary.BigEndian.Uint16(c.data[20:]), binary.BigEndian.Uint16(c.data[22:])}; delay != [2]uint16{1, 20} {
+				t.Fatalf("frame %d has delay %d/%d instead of 1/20", decoded, delay[0], delay[1])
+			}
+		case "IDAT", "fdAT":
+			data := c.data
+			if c.name == "fdAT" {
+				data = data[4:] // sequence number
+			}
+
+			// the frame data is the image data of a PNG with the same header
+			var frame bytes.Buffer
+			frame.WriteString("\x89PNG\r\n\x1a\n")
+			writeTestChunk(&frame, "IHDR", chunks[0].data)
+			writeTestChunk(&frame, "IDAT", data)
+			writeTestChunk(&frame, "IEND", nil)
+			img, err := png.Decode(&frame)
+			if err != nil {
+				t.Fatalf("frame %d: %v", decoded, err)
+			}
+			if diff := samePixels(img, frames[decoded]); diff != "" {
+				t.Fatalf("frame %d: %s", decoded, diff)
+			}
+			decoded++
+		}
+	}
+	if decoded != len(frames) {
+		t.Fatalf("%d frames decoded instead of %d", decoded, len(frames))
+	}
+}
+
+// pngChunk is a chunk of a PNG file
+type pngChunk struct {
+	name string
+	data []byte
+}
+
+// readChunks splits a PNG file in its chunks, checking their checksums
+func readChunks(file []byte) ([]pngChunk, error) {
+
+	if !bytes.HasPrefix(file, []byte("\x89PNG\r\n\x1a\n")) {
+		return nil, errors.New("Missing PNG signature")
+	}
+	chunks := []pngChunk{}
+	for rest := file[8:]; len(rest) > 0; {
+		if len(rest) < 12 {
+			return nil, errors.New("Truncated chunk")
+		}
+		length := int(binary.BigEndian.Uint32(rest))
+		body := rest[4 : 8+length]
+		if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(rest[8+length:]) {
+			return nil, fmt.Errorf("Wrong checksum of the %s chunk", body[:4])
+		}
+		chunks = append(chunks, pngChunk{name: string(body[:4]), data: body[4:]})
+		rest = rest[12+length:]
+	}
+	return chunks, nil
+}
+
+// writeTestChunk writes a PNG chunk
+func writeTestChunk(w io.Writer, name string, data []byte) {
+
+	body := append([]byte(name), data...)
+	binary.Write(w, binary.BigEndian, uint32(len(data)))
+	w.Write(body)
+	binary.Write(w, binary.BigEndian, crc32.ChecksumIEEE(body))
+}
+
+// TestY4MRoundTrip reads a YUV4MPEG2 video: the header, and the full range YCbCr planes of each frame
+func TestY4MRoundTrip(t *testing.T) {
+
+	_, frames := growth(t)
+
+	var buf bytes.Buffer
+	if err := writeAnimation(NewY4MWriter(&buf, 30), frames); err != nil {
+		t.Fatal(err)
+	}
+
+	r := bufio.NewReader(&buf)
+	header, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header != "YUV4MPEG2 W40 H30 F30:1 Ip A1:1 C444 XCOLORRANGE=FULL\n" {
+		t.Fatalf("Unexpected header %q", header)
+	}
+
+	planes := make([]byte, 40*30*3)
+	for i, frame := range frames {
+		if line, err := r.ReadString('\n'); err != nil || line != "FRAME\n" {
+			t.Fatalf("frame %d: %q, %v", i, line, err)
+		}
+		if _, err := io.ReadFull(r, planes); err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+
+		img := &image.YCbCr{Y: planes[:1200], Cb: planes[1200:2400], Cr: planes[2400:], YStride: 40, CStride: 40,
+			SubsampleRatio: image.YCbCrSubsampleRatio444, Rect: image.Rect(0, 0, 40, 30)}
+		for pos := 0; pos < 1200; pos++ {
+			c := frame.RGBAAt(pos%40, pos/40)
+			y, cb, cr := color.RGBToYCbCr(c.R, c.G, c.B)
+			if img.Y[pos] != y || img.Cb[pos] != cb || img.Cr[pos] != cr {
+				t.Fatalf("frame %d: (%d, %d) is %v instead of the conversion of %v", i, pos%40, pos/40, img.YCbCrAt(pos%40, pos/40), c)
+			}
+		}
+	}
+	if _, err := r.ReadByte(); err != io.EOF {
+		t.Fatal("Unexpected data after the frames")
+	}
+}
+
+// failingWriter accepts a number of bytes, then fails
+type failingWriter struct {
+	left int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.left {
+		n := w.left
+		w.left = 0
+		return n, errors.New("disk full")
+	}
+	w.left -= len(p)
+	return len(p), nil
+}
+
+// TestAnimationWriteErrors checks that the errors of the underlying writer are reported, and stop the animation
+func TestAnimationWriteErrors(t *testing.T) {
+
+	seeds, frames := growth(t)
+
+	writers := map[string]func(io.Writer) AnimationWriter{
+		"gif":  func(w io.Writer) AnimationWriter { return NewGIFWriter(w, seeds, 25) },
+		"apng": func(w io.Writer) AnimationWriter { return NewAPNGWriter(w, 25) },
+		"y4m":  func(w io.Writer) AnimationWriter { return NewY4MWriter(w, 25) },
+	}
+	for name, newWriter := range writers {
+		for _, left := range []int{0, 100, 2000} {
+			err := writeAnimation(newWriter(&failingWriter{left: left}), frames)
+			if err == nil || !strings.Contains(err.Error(), "disk full") {
+				t.Fatalf("%s, failing after %d bytes: the error is %v", name, left, err)
+			}
+		}
+	}
+}
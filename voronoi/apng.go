@@ -0,0 +1,166 @@
+package voronoi
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"io"
+	"os"
+)
+
+// apngWriter encodes an animated PNG
+type apngWriter struct {
+	w        io.Writer
+	fps      int
+	size     image.Point   // size of the frames, taken from the first one
+	frames   int           // number of frames written
+	sequence uint32        // sequence number of the next frame control or frame data
+	temp     *os.File      // temporary file holding the chunks of the frames
+	buffer   *bufio.Writer // buffer of the temporary file
+	row      []byte        // buffer of a row of the frame being compressed
+	chunk    []byte        // buffer of the chunk being written
+}
+
+/*
+NewAPNGWriter creates an animation writer encoding an animated PNG (APNG), looping forever at the given frame rate.
+The frames are lossless and opaque, as in the viewer.
+The number of frames comes before them in the file, so the frames are compressed and written to a temporary file
+as they are added, then copied when the writer is closed
+*/
+func NewAPNGWriter(w io.Writer, fps int) AnimationWriter {
+	return &apngWriter{
+		w:   w,
+		fps: fps,
+	}
+}
+
+// WriteFrame compresses a frame, and writes its chunks to the temporary file
+func (a *apngWriter) WriteFrame(frame *image.RGBA) error {
+
+	size := frame.Bounds().Size()
+	if a.frames == 0 {
+		temp, err := os.CreateTemp("", "voronoi-*.apng")
+		if err != nil {
+			return err
+		}
+		a.size = size
+		a.temp = temp
+		a.buffer = bufio.NewWriter(temp)
+		a.row = make([]byte, 1+size.X*3)
+	} else if size != a.size {
+		return errors.New("All the frames of the animation must have the same size")
+	}
+
+	// each row of RGB pixels starts with the filter type (0, none)
+	var data bytes.Buffer
+	z := zlib.NewWriter(&data)
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			copy(a.row[1+x*3:1+x*3+3], frame.Pix[y*frame.Stride+x*4:])
+		}
+		if _, err := z.Write(a.row); err != nil {
+			return err
+		}
+	}
+	if err := z.Close(); err != nil {
+		return err
+	}
+
+	// the frame controls and the frame data share the same sequence
+	err := a.writeChunk(a.buffer, "fcTL",
+		a.sequence,
+		uint32(a.size.X), uint32(a.size.Y),
+		uint32(0), uint32(0), // offset of the frame
+		uint16(1), uint16(a.fps), // delay of the frame (1/fps seconds)
+		[]byte{0, 0}, // no disposal, the frame replaces the previous one
+	)
+	if err != nil {
+		return err
+	}
+	a.sequence++
+
+	// the first frame is also the default image, for the decoders that don't support the animations
+	if a.frames == 0 {
+		err = a.writeChunk(a.buffer, "IDAT", data.Bytes())
+	} else {
+		err = a.writeChunk(a.buffer, "fdAT", a.sequence, data.Bytes())
+		a.sequence++
+	}
+	if err != nil {
+		return err
+	}
+
+	a.frames++
+	return nil
+}
+
+// Close writes the animation, and removes the temporary file
+func (a *apngWriter) Close() error {
+
+	if a.frames == 0 {
+		return errors.New("The animation has no frames")
+	}
+	defer os.Remove(a.temp.Name())
+	defer a.temp.Close()
+
+	if err := a.buffer.Flush(); err != nil {
+		return err
+	}
+	if _, err := a.temp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	b := bufio.NewWriter(a.w)
+	if _, err := b.WriteString("\x89PNG\r\n\x1a\n"); err != nil {
+		return err
+	}
+
+	// 8 bits per channel, RGB
+	if err := a.writeChunk(b, "IHDR", uint32(a.size.X), uint32(a.size.Y), []byte{8, 2, 0, 0, 0}); err != nil {
+		return err
+	}
+
+	// number of frames, and number of loops (0 is forever)
+	if err := a.writeChunk(b, "acTL", uint32(a.frames), uint32(0)); err != nil {
+		return err
+	}
+
+	if _, err := b.ReadFrom(a.temp); err != nil {
+		return err
+	}
+
+	if err := a.writeChunk(b, "IEND"); err != nil {
+		return err
+	}
+	return b.Flush()
+}
+
+// writeChunk writes a PNG chunk, whose data is the concatenation of the given fields (big endian numbers and byte slices)
+func (a *apngWriter) writeChunk(w *bufio.Writer, name string, fields ...interface{}) error {
+
+	a.chunk = append(a.chunk[:0], name...)
+	for _, f := range fields {
+		switch v := f.(type) {
+		case uint32:
+			a.chunk = binary.BigEndian.AppendUint32(a.chunk, v)
+		case uint16:
+			a.chunk = binary.BigEndian.AppendUint16(a.chunk, v)
+		case []byte:
+			a.chunk = append(a.chunk, v...)
+		}
+	}
+
+	// the length doesn't include the name, the checksum does
+	if _, err := w.Write(binary.BigEndian.AppendUint32(nil, uint32(len(a.chunk)-4))); err != nil {
+		return err
+	}
+	if _, err := w.Write(a.chunk); err != nil {
+		return err
+	}
+	_, err := w.Write(binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(a.chunk)))
+	return err
+}
@@ -0,0 +1,309 @@
+package voronoi
+
+// arc is a section of the beach line of the Fortune's algorithm: a parabolic arc generated by a site.
+// The beach line is stored as a red-black tree of arcs, whose in-order traversal
+// (also available through the previous/next links) is the left-to-right sequence of arcs
+type arc struct {
+	site   *site
+	edge   *sweepEdge   // edge traced by the breakpoint between this arc and the previous one
+	circle *circleEvent // circle event that will make this arc disappear (nil if none)
+
+	// red-black tree links
+	parent   *arc
+	left     *arc
+	right    *arc
+	previous *arc
+	next     *arc
+	red      bool
+}
+
+// beachLine is the red-black tree holding the arcs of the beach line
+type beachLine struct {
+	root *arc
+}
+
+// first returns the leftmost arc of the subtree rooted in node
+func (t *beachLine) first(node *arc) *arc {
+	for node.left != nil {
+		node = node.left
+	}
+	return node
+}
+
+// insert places successor right after node in the beach line
+// (or at the beginning of it, if node is nil) and rebalances the tree
+func (t *beachLine) insert(node *arc, successor *arc) {
+
+	var parent *arc
+
+	if node != nil {
+		successor.previous = node
+		successor.next = node.next
+		if node.next != nil {
+			node.next.previous = successor
+		}
+		node.next = successor
+
+		if node.right != nil {
+			node = t.first(node.right)
+			node.left = successor
+		} else {
+			node.right = successor
+		}
+		parent = node
+
+	} else if t.root != nil {
+		node = t.first(t.root)
+		successor.previous = nil
+		successor.next = node
+		node.previous = successor
+		node.left = successor
+		parent = node
+
+	} else {
+		successor.previous = nil
+		successor.next = nil
+		t.root = successor
+		parent = nil
+	}
+
+	successor.left = nil
+	successor.right = nil
+	successor.parent = parent
+	successor.red = true
+
+	// restore the red-black properties
+	node = successor
+	for parent != nil && parent.red {
+		grandpa := parent.parent
+
+		if parent == grandpa.left {
+			uncle := grandpa.right
+			if uncle != nil && uncle.red {
+				parent.red = false
+				uncle.red = false
+				grandpa.red = true
+				node = grandpa
+			} else {
+				if node == parent.right {
+					t.rotateLeft(parent)
+					node = parent
+					parent = node.parent
+				}
+				parent.red = false
+				grandpa.red = true
+				t.rotateRight(grandpa)
+			}
+
+		} else {
+			uncle := grandpa.left
+			if uncle != nil && uncle.red {
+				parent.red = false
+				uncle.red = false
+				grandpa.red = true
+				node = grandpa
+			} else {
+				if node == parent.left {
+					t.rotateRight(parent)
+					node = parent
+					parent = node.parent
+				}
+				parent.red = false
+				grandpa.red = true
+				t.rotateLeft(grandpa)
+			}
+		}
+		parent = node.parent
+	}
+	t.root.red = false
+}
+
+// remove takes node out of the beach line and rebalances the tree
+func (t *beachLine) remove(node *arc) {
+
+	if node.next != nil {
+		node.next.previous = node.previous
+	}
+	if node.previous != nil {
+		node.previous.next = node.next
+	}
+	node.next = nil
+	node.previous = nil
+
+	parent := node.parent
+	left := node.left
+	right := node.right
+
+	var next *arc
+	if left == nil {
+		next = right
+	} else if right == nil {
+		next = left
+	} else {
+		next = t.first(right)
+	}
+
+	if parent != nil {
+		if parent.left == node {
+			parent.left = next
+		} else {
+			parent.right = next
+		}
+	} else {
+		t.root = next
+	}
+
+	var isRed bool
+	if left != nil && right != nil {
+		isRed = next.red
+		next.red = node.red
+		next.left = left
+		left.parent = next
+
+		if next != right {
+			parent = next.parent
+			next.parent = node.parent
+			node = next.right
+			parent.left = node
+			next.right = right
+			right.parent = next
+		} else {
+			next.parent = parent
+			parent = next
+			node = next.right
+		}
+
+	} else {
+		isRed = node.red
+		node = next
+	}
+
+	if node != nil {
+		node.parent = parent
+	}
+
+	// restore the red-black properties
+	if isRed {
+		return
+	}
+	if node != nil && node.red {
+		node.red = false
+		return
+	}
+
+	for {
+		if node == t.root {
+			break
+		}
+
+		var sibling *arc
+		if node == parent.left {
+			sibling = parent.right
+			if sibling.red {
+				sibling.red = false
+				parent.red = true
+				t.rotateLeft(parent)
+				sibling = parent.right
+			}
+			if (sibling.left != nil && sibling.left.red) || (sibling.right != nil && sibling.right.red) {
+				if sibling.right == nil || !sibling.right.red {
+					sibling.left.red = false
+					sibling.red = true
+					t.rotateRight(sibling)
+					sibling = parent.right
+				}
+				sibling.red = parent.red
+				parent.red = false
+				sibling.right.red = false
+				t.rotateLeft(parent)
+				node = t.root
+				break
+			}
+
+		} else {
+			sibling = parent.left
+			if sibling.red {
+				sibling.red = false
+				parent.red = true
+				t.rotateRight(parent)
+				sibling = parent.left
+			}
+			if (sibling.left != nil && sibling.left.red) || (sibling.right != nil && sibling.right.red) {
+				if sibling.left == nil || !sibling.left.red {
+					sibling.right.red = false
+					sibling.red = true
+					t.rotateLeft(sibling)
+					sibling = parent.left
+				}
+				sibling.red = parent.red
+				parent.red = false
+				sibling.left.red = false
+				t.rotateRight(parent)
+				node = t.root
+				break
+			}
+		}
+
+		sibling.red = true
+		node = parent
+		parent = parent.parent
+
+		if node.red {
+			break
+		}
+	}
+
+	if node != nil {
+		node.red = false
+	}
+}
+
+// rotateLeft rotates the subtree rooted in node to the left
+func (t *beachLine) rotateLeft(node *arc) {
+	p := node
+	q := node.right
+	parent := p.parent
+
+	if parent != nil {
+		if parent.left == p {
+			parent.left = q
+		} else {
+			parent.right = q
+		}
+	} else {
+		t.root = q
+	}
+
+	q.parent = parent
+	p.parent = q
+	p.right = q.left
+	if p.right != nil {
+		p.right.parent = p
+	}
+	q.left = p
+}
+
+// rotateRight rotates the subtree rooted in node to the right
+func (t *beachLine) rotateRight(node *arc) {
+	p := node
+	q := node.left
+	parent := p.parent
+
+	if parent != nil {
+		if parent.left == p {
+			parent.left = q
+		} else {
+			parent.right = q
+		}
+	} else {
+		t.root = q
+	}
+
+	q.parent = parent
+	p.parent = q
+	p.left = q.right
+	if p.left != nil {
+		p.left.parent = p
+	}
+	q.right = p
+}
@@ -0,0 +1,117 @@
+package voronoi
+
+// BruteForce is the engine computing the exact voronoi diagram by finding, for each pixel, its nearest seed.
+// It is highly inefficient, but trivially correct: it is meant to be used as a reference for the other engines
+type BruteForce struct {
+
+	// diagram size (in pixels)
+	width  int
+	height int
+
+	// seed configuration of the diagram
+	numSeeds int     // number of seeds for the diagram
+	seeds    []Point // list of seeds for the diagram
+
+	diagram *Diagram // resulting diagram (nil until the tessellation is computed)
+
+	config // optional settings (including the random generation of the seeds, see seeding)
+}
+
+// NewBruteForce creates a new diagram struct computed with the brute force algorithm.
+// If explicit seeds are provided through the options, numSeeds is ignored
+func NewBruteForce(
+	width int,
+	height int,
+	numSeeds int,
+	opts ...Option,
+) (*BruteForce, error) {
+
+	c, numSeeds, err := setup(width, height, numSeeds, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BruteForce{
+		width:    width,
+		height:   height,
+		numSeeds: numSeeds,
+		seeds:    []Point{},
+		config:   c,
+	}, nil
+}
+
+// Init initializes the diagram and generates a new set of seeds
+func (b *BruteForce) Init() {
+	b.seeds = generateSeeds(b.width, b.height, b.numSeeds, b.config)
+	b.diagram = nil
+}
+
+// SetSeeds replaces the seeds of the diagram, and restarts the tessellation
+func (b *BruteForce) SetSeeds(seeds []Point) error {
+
+	s, err := replaceSeeds(b.width, b.height, seeds, b.config)
+	if err != nil {
+		return err
+	}
+	b.seeds = s
+	b.diagram = nil
+
+	return nil
+}
+
+// Tessellate computes the voronoi diagram.
+// The whole diagram is computed at the first call regardless of hideIterations
+func (b *BruteForce) Tessellate(hideIterations bool) error {
+
+	if b.diagram != nil {
+		return nil
+	}
+
+	d := newDiagram(b.width, b.height, b.seeds, b.config)
+
+	for j := 0; j < b.height; j++ {
+		for i := 0; i < b.width; i++ {
+			pos := j*b.width + i
+
+			// in case of a tie, the pixel goes to the seed coming first
+			for s, seed := range b.seeds {
+				distance := b.config.distance(seed, b.config.wrap(i-seed.X, b.width), b.config.wrap(j-seed.Y, b.height))
+
+				if d.Labels[pos] < 0 || distance < d.Distances[pos] {
+					d.Labels[pos] = s
+					d.Distances[pos] = distance
+				}
+			}
+		}
+	}
+
+	b.diagram = d
+	return nil
+}
+
+// Done reports whether the tessellation is complete
+func (b *BruteForce) Done() bool {
+	return b.diagram != nil
+}
+
+// Diagram returns a snapshot of the current state of the tessellation
+func (b *BruteForce) Diagram() *Diagram {
+
+	d := newDiagram(b.width, b.height, b.seeds, b.config)
+	if b.diagram != nil {
+		copy(d.Labels, b.diagram.Labels)
+		copy(d.Distances, b.diagram.Distances)
+	}
+
+	return d
+}
+
+// ToPixels generates the byte array containing the information to render the diagram.
+// Each row of the canvas is concatenated to obtain a one-dimensional array.
+// Each pixel is represented by 4 bytes, representing the Red, Green, Blue and Alpha info.
+func (b *BruteForce) ToPixels() []byte {
+	if b.diagram == nil {
+		return newDiagram(b.width, b.height, b.seeds, b.config).ToPixels()
+	}
+	return b.diagram.ToPixels()
+}
@@ -0,0 +1,105 @@
+package voronoi
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Comparison reports the differences between a diagram and an exact reference diagram with the same seeds
+type Comparison struct {
+
+	// diagram size (in pixels)
+	Width  int
+	Height int
+
+	// Mismatches lists the pixels assigned to a seed farther than the one of the reference diagram.
+	// Pixels equidistant from more seeds are not mismatches, whatever seed they are assigned to
+	Mismatches []image.Point
+
+	// Unassigned counts the pixels not assigned to any seed yet
+	Unassigned int
+
+	diagram *Diagram // compared diagram
+}
+
+// Compare checks the pixels of the diagram against the reference one (e.g. computed by a BruteForce engine).
+// The diagrams must have the same size and the same seeds
+func Compare(d *Diagram, reference *Diagram) (*Comparison, error) {
+
+	if d.Width != reference.Width || d.Height != reference.Height {
+		return nil, errors.New("Cannot compare diagrams with different sizes")
+	}
+	if len(d.Seeds) != len(reference.Seeds) {
+		return nil, errors.New("Cannot compare diagrams with different seeds")
+	}
+	for i := range d.Seeds {
+		if d.Seeds[i].X != reference.Seeds[i].X || d.Seeds[i].Y != reference.Seeds[i].Y {
+			return nil, fmt.Errorf("Cannot compare diagrams with different seeds (seed %d differs)", i)
+		}
+	}
+
+	c := &Comparison{
+		Width:      d.Width,
+		Height:     d.Height,
+		Mismatches: []image.Point{},
+		diagram:    d,
+	}
+
+	for pos, l := range d.Labels {
+		if l < 0 {
+			c.Unassigned++
+			continue
+		}
+
+		if l != reference.Labels[pos] && d.Distances[pos] > reference.Distances[pos] {
+			c.Mismatches = append(c.Mismatches, image.Point{X: pos % d.Width, Y: pos / d.Width})
+		}
+	}
+
+	return c, nil
+}
+
+// Percentage returns the percentage of the pixels of the canvas that are mismatches
+func (c *Comparison) Percentage() float64 {
+	if c.Width*c.Height == 0 {
+		return 0
+	}
+	return 100 * float64(len(c.Mismatches)) / float64(c.Width*c.Height)
+}
+
+// String summarizes the comparison
+func (c *Comparison) String() string {
+	return fmt.Sprintf(
+		"%d mismatching pixels out of %d (%.4f%%), %d unassigned",
+		len(c.Mismatches),
+		c.Width*c.Height,
+		c.Percentage(),
+		c.Unassigned,
+	)
+}
+
+// Overlay renders the error overlay of the comparison:
+// the compared diagram is shown dimmed, with the mismatching pixels highlighted in red
+func (c *Comparison) Overlay() *image.RGBA {
+
+	img := image.NewRGBA(image.Rect(0, 0, c.Width, c.Height))
+	pixels := c.diagram.ToPixels()
+
+	// dim the diagram, as a grayscale image
+	for pos := 0; pos < c.Width*c.Height; pos++ {
+		gray := (uint16(pixels[pos*4]) + uint16(pixels[pos*4+1]) + uint16(pixels[pos*4+2])) / 3 / 3
+		img.Pix[pos*4] = uint8(gray)
+		img.Pix[pos*4+1] = uint8(gray)
+		img.Pix[pos*4+2] = uint8(gray)
+		img.Pix[pos*4+3] = 0xff
+	}
+
+	red := color.RGBA{R: 0xff, A: 0xff}
+	for _, p := range c.Mismatches {
+		img.SetRGBA(p.X, p.Y, red)
+	}
+
+	return img
+}
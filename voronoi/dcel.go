@@ -0,0 +1,143 @@
+package voronoi
+
+import (
+	"math"
+)
+
+// Vertex is a vertex of the exact voronoi diagram
+type Vertex struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// HalfEdge is one of the two oriented sides of an edge of the diagram.
+// The half-edges bounding a cell are linked in a cycle, walking counterclockwise around it
+// (as seen on the canvas, where y grows downward)
+type HalfEdge struct {
+	Origin *Vertex   // vertex the half-edge starts from
+	Twin   *HalfEdge // opposite side of the same edge
+	Next   *HalfEdge // next half-edge around the cell
+	Prev   *HalfEdge // previous half-edge around the cell
+	Cell   *Cell     // cell on the side of the half-edge (nil for the outside of the canvas)
+}
+
+// Destination returns the vertex the half-edge ends in
+func (h *HalfEdge) Destination() *Vertex {
+	return h.Twin.Origin
+}
+
+// Cell is the region of the canvas closer to a seed than to any other seed
+type Cell struct {
+	Seed     int       // index of the seed of the cell
+	HalfEdge *HalfEdge // one of the half-edges bounding the cell (nil if the cell is empty)
+}
+
+// Polygon returns the vertices of the cell, in the order they are walked around it
+func (c *Cell) Polygon() []Vertex {
+
+	polygon := []Vertex{}
+	if c.HalfEdge == nil {
+		return polygon
+	}
+
+	h := c.HalfEdge
+	for {
+		polygon = append(polygon, *h.Origin)
+		h = h.Next
+		if h == c.HalfEdge {
+			break
+		}
+	}
+
+	return polygon
+}
+
+// DCEL is the doubly-connected edge list describing the exact voronoi diagram, clipped to the canvas.
+// The border of the canvas is part of the diagram: its outer half-edges have no cell
+type DCEL struct {
+	Vertices []*Vertex
+	Edges    []*HalfEdge // one half-edge for each edge of the diagram (the other side is its twin)
+	Cells    []*Cell     // the cell of each seed, in the same order of the seeds (followed by the pieces wrapping around the borders, on a toroidal canvas)
+}
+
+// newDCEL builds the doubly-connected edge list from the result of the Fortune's algorithm
+func newDCEL(s *sweep) *DCEL {
+
+	d := &DCEL{
+		Vertices: []*Vertex{},
+		Edges:    []*HalfEdge{},
+		Cells:    make([]*Cell, len(s.cells)),
+	}
+
+	// the same point may be generated more than once (e.g. when the cells are closed along the border):
+	// the vertices are deduplicated by position
+	vertices := map[[2]int64]*Vertex{}
+	vertex := func(v *sweepVertex) *Vertex {
+		key := [2]int64{int64(math.Round(v.x / epsilon / 1000)), int64(math.Round(v.y / epsilon / 1000))}
+		if existing, found := vertices[key]; found {
+			return existing
+		}
+		created := &Vertex{X: v.x, Y: v.y}
+		vertices[key] = created
+		d.Vertices = append(d.Vertices, created)
+		return created
+	}
+
+	halfEdges := map[*sweepEdge]*HalfEdge{} // first half-edge created for each edge
+	outer := []*HalfEdge{}                  // half-edges running along the outside of the canvas
+
+	for i, sc := range s.cells {
+		cell := &Cell{Seed: i}
+		d.Cells[i] = cell
+
+		if sc == nil || len(sc.halfEdges) == 0 {
+			continue
+		}
+
+		cycle := []*HalfEdge{}
+		for _, sh := range sc.halfEdges {
+			h := &HalfEdge{
+				Origin: vertex(sh.start()),
+				Cell:   cell,
+			}
+			cycle = append(cycle, h)
+
+			if twin, found := halfEdges[sh.edge]; found {
+				h.Twin = twin
+				twin.Twin = h
+			} else {
+				halfEdges[sh.edge] = h
+				d.Edges = append(d.Edges, h)
+			}
+
+			// the border edges have no other cell on the opposite side
+			if sh.edge.rSite == nil {
+				h.Twin = &HalfEdge{
+					Origin: vertex(sh.end()),
+					Twin:   h,
+				}
+				outer = append(outer, h.Twin)
+			}
+		}
+
+		for j, h := range cycle {
+			h.Next = cycle[(j+1)%len(cycle)]
+			h.Prev = cycle[(j+len(cycle)-1)%len(cycle)]
+		}
+		cell.HalfEdge = cycle[0]
+	}
+
+	// link the outer half-edges in a cycle around the canvas
+	byOrigin := map[*Vertex]*HalfEdge{}
+	for _, h := range outer {
+		byOrigin[h.Origin] = h
+	}
+	for _, h := range outer {
+		if next, found := byOrigin[h.Destination()]; found {
+			h.Next = next
+			next.Prev = h
+		}
+	}
+
+	return d
+}
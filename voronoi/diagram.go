@@ -0,0 +1,205 @@
+package voronoi
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// Diagram is the result of a tessellation, detached from the engine that computed it
+type Diagram struct {
+
+	// diagram size (in pixels)
+	Width  int
+	Height int
+
+	// seeds of the diagram: the labels refer to the position of the seeds in this list
+	Seeds []Point
+
+	// Labels contains, row by row, the index of the seed of the cell each pixel belongs to
+	// (-1 if the pixel is not assigned yet)
+	Labels []int
+
+	// Distances contains, row by row, the distance of each pixel from the seed of its cell
+	// (meaningless for the pixels not assigned yet)
+	Distances []float64
+
+	// Metric is the metric used to measure the distances
+	Metric Metric
+
+	// Weighting is the way the weights of the seeds affect the distances
+	Weighting Weighting
+
+	// Toroidal is true if the canvas wraps around both axes
+	Toroidal bool
+}
+
+// newDiagram creates an empty diagram for the given seeds, with all the pixels unassigned
+func newDiagram(width int, height int, seeds []Point, c config) *Diagram {
+	d := &Diagram{
+		Width:     width,
+		Height:    height,
+		Seeds:     append([]Point{}, seeds...),
+		Labels:    make([]int, width*height),
+		Distances: make([]float64, width*height),
+		Metric:    c.metric,
+		Weighting: c.weighting,
+		Toroidal:  c.toroidal,
+	}
+	for i := range d.Labels {
+		d.Labels[i] = -1
+	}
+	return d
+}
+
+// Label returns the index of the seed of the cell the pixel (x, y) belongs to, or -1 if it is not assigned yet
+func (d *Diagram) Label(x int, y int) int {
+	return d.Labels[y*d.Width+x]
+}
+
+// ToPixels generates the byte array containing the information to render the diagram,
+// with the same layout used by the engines
+func (d *Diagram) ToPixels() []byte {
+	pixels := make([]byte, d.Width*d.Height*4)
+
+	for pos, l := range d.Labels {
+
+		// the pixels without a color (unassigned or belonging to a seed without color) are left black
+		if l < 0 || d.Seeds[l].Color == nil {
+			continue
+		}
+		c := d.Seeds[l].Color
+		pixels[pos*4] = c.R
+		pixels[pos*4+1] = c.G
+		pixels[pos*4+2] = c.B
+		pixels[pos*4+3] = c.A
+	}
+
+	// render the seeds as black points
+	drawSeeds(pixels, d.Width, d.Height, d.Seeds, d.Weighting, d.Toroidal)
+
+	return pixels
+}
+
+// Image renders the diagram as an opaque image, as it's shown by the viewer
+func (d *Diagram) Image() *image.RGBA {
+
+	img := image.NewRGBA(image.Rect(0, 0, d.Width, d.Height))
+	copy(img.Pix, d.ToPixels())
+
+	// the colors of the seeds have a random alpha, but the viewer shows them on an opaque black background
+	for pos := 3; pos < len(img.Pix); pos += 4 {
+		img.Pix[pos] = 255
+	}
+
+	return img
+}
+
+// EmptyCells returns the indexes of the seeds whose cell doesn't contain any pixel.
+// With the power weighting, a seed can be dominated by the larger seeds around it;
+// otherwise it happens only to the seeds sharing the same pixel with another one
+func (d *Diagram) EmptyCells() []int {
+
+	sizes := make([]int, len(d.Seeds))
+	for _, l := range d.Labels {
+		if l >= 0 {
+			sizes[l]++
+		}
+	}
+
+	empty := []int{}
+	for i, size := range sizes {
+		if size == 0 {
+			empty = append(empty, i)
+		}
+	}
+	return empty
+}
+
+// Neighbors returns, for each seed, the sorted indexes of the seeds whose cells share an edge with its cell
+// (across the borders, on a toroidal canvas)
+func (d *Diagram) Neighbors() [][]int {
+
+	adjacent := make([]map[int]bool, len(d.Seeds))
+	for i := range adjacent {
+		adjacent[i] = map[int]bool{}
+	}
+
+	link := func(a int, b int) {
+		if a >= 0 && b >= 0 && a != b {
+			adjacent[a][b] = true
+			adjacent[b][a] = true
+		}
+	}
+
+	// each pixel is compared with the one on its right and the one below it
+	for y := 0; y < d.Height; y++ {
+		for x := 0; x < d.Width; x++ {
+			l := d.Labels[y*d.Width+x]
+			if x+1 < d.Width {
+				link(l, d.Labels[y*d.Width+x+1])
+			} else if d.Toroidal {
+				link(l, d.Labels[y*d.Width])
+			}
+			if y+1 < d.Height {
+				link(l, d.Labels[(y+1)*d.Width+x])
+			} else if d.Toroidal {
+				link(l, d.Labels[x])
+			}
+		}
+	}
+
+	neighbors := make([][]int, len(d.Seeds))
+	for i, a := range adjacent {
+		neighbors[i] = []int{}
+		for n := range a {
+			neighbors[i] = append(neighbors[i], n)
+		}
+		sort.Ints(neighbors[i])
+	}
+	return neighbors
+}
+
+// drawSeeds renders the seeds as black points in the RGBA byte array of a width*height canvas.
+// With the power weighting, the circle of each seed is drawn as well,
+// so that even the seeds with an empty cell are visible (wrapping around the borders, on a toroidal canvas)
+func drawSeeds(pixels []byte, width int, height int, seeds []Point, weighting Weighting, toroidal bool) {
+
+	black := func(x int, y int) {
+		if toroidal {
+			x = mod(x, width)
+			y = mod(y, height)
+		}
+		if x < 0 || x >= width || y < 0 || y >= height {
+			return
+		}
+		pos := (y*width + x) * 4
+		pixels[pos] = 0
+		pixels[pos+1] = 0
+		pixels[pos+2] = 0
+		pixels[pos+3] = 0
+	}
+
+	for _, s := range seeds {
+		black(s.X, s.Y)
+
+		if weighting != Power || s.Radius < 1 {
+			continue
+		}
+
+		// the outline of the circle is made of the pixels whose distance from the seed rounds to the radius
+		r := int(math.Ceil(s.Radius))
+		for dy := -r; dy <= r; dy++ {
+			for dx := -r; dx <= r; dx++ {
+				if math.Abs(math.Hypot(float64(dx), float64(dy))-s.Radius) < 0.5 {
+					black(s.X+dx, s.Y+dy)
+				}
+			}
+		}
+	}
+}
+
+// mod is the modulo operation, always returning a value between 0 and n-1 (unlike the % operator)
+func mod(a int, n int) int {
+	return ((a % n) + n) % n
+}
@@ -0,0 +1,363 @@
+package voronoi
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// SeedGenerator places the random seeds of a diagram
+type SeedGenerator interface {
+
+	// Generate returns the positions of (at most) numSeeds seeds in a width*height canvas,
+	// drawing the random numbers from r. Only the coordinates of the returned points are set
+	Generate(width int, height int, numSeeds int, r *rand.Rand) []Point
+
+	// String returns the name of the distribution
+	String() string
+}
+
+// SeedGenerators returns the available distributions of the seeds, with their default settings
+func SeedGenerators() []SeedGenerator {
+	return []SeedGenerator{
+		Uniform{},
+		PoissonDisk{},
+		JitteredGrid{Jitter: 1},
+		HexGrid{},
+		Halton{},
+		Clusters{},
+	}
+}
+
+// ParseSeedGenerator returns the distribution of the seeds with the given name
+// (uniform, poisson, jittered, hex, halton or clusters), with its default settings
+func ParseSeedGenerator(name string) (SeedGenerator, error) {
+
+	for _, g := range SeedGenerators() {
+		if strings.ToLower(name) == g.String() {
+			return g, nil
+		}
+	}
+	if name == "" {
+		return Uniform{}, nil
+	}
+	return nil, fmt.Errorf("Unknown seed distribution %q", name)
+}
+
+// Uniform places the seeds independently and uniformly at random (the default distribution)
+type Uniform struct{}
+
+func (Uniform) String() string {
+	return "uniform"
+}
+
+// Generate returns numSeeds uniformly random positions
+func (Uniform) Generate(width int, height int, numSeeds int, r *rand.Rand) []Point {
+
+	points := make([]Point, numSeeds)
+	for i := range points {
+		points[i] = Point{X: r.Intn(width), Y: r.Intn(height)}
+	}
+	return points
+}
+
+/*
+PoissonDisk places the seeds with the Bridson's algorithm, so that no two seeds are closer than a minimum spacing
+(blue noise): the cells have similar sizes, without the regularity of a grid.
+
+A zero spacing is chosen from the number of seeds, so that the canvas holds slightly more of them than requested.
+The whole canvas is filled first, and then the requested number of seeds is picked at random:
+with a larger spacing the canvas may be full before reaching it, and fewer seeds are returned
+*/
+type PoissonDisk struct {
+	Spacing float64 // minimum distance between two seeds (in pixels)
+}
+
+func (PoissonDisk) String() string {
+	return "poisson"
+}
+
+// Generate returns up to numSeeds positions with the minimum spacing
+func (p PoissonDisk) Generate(width int, height int, numSeeds int, r *rand.Rand) []Point {
+
+	if numSeeds == 0 {
+		return []Point{}
+	}
+
+	spacing := p.Spacing
+	if spacing <= 0 {
+		// a maximal Poisson-disk sample covers about 0.7/spacing^2 points per pixel
+		spacing = 0.75 * math.Sqrt(float64(width*height)/float64(numSeeds))
+	}
+
+	// candidates tested around each active sample before retiring it
+	const attempts = 30
+
+	// background grid with cells small enough to hold at most a sample each
+	cellSize := spacing / math.Sqrt2
+	cols := int(math.Ceil(float64(width)/cellSize)) + 1
+	rows := int(math.Ceil(float64(height)/cellSize)) + 1
+	grid := make([]int, cols*rows)
+	for i := range grid {
+		grid[i] = -1
+	}
+
+	samples := [][2]float64{}
+	active := []int{}
+
+	add := func(x float64, y float64) {
+		grid[int(y/cellSize)*cols+int(x/cellSize)] = len(samples)
+		active = append(active, len(samples))
+		samples = append(samples, [2]float64{x, y})
+	}
+
+	fits := func(x float64, y float64) bool {
+		if x < 0 || x >= float64(width) || y < 0 || y >= float64(height) {
+			return false
+		}
+		cx := int(x / cellSize)
+		cy := int(y / cellSize)
+		for j := cy - 2; j <= cy+2; j++ {
+			for i := cx - 2; i <= cx+2; i++ {
+				if i < 0 || i >= cols || j < 0 || j >= rows || grid[j*cols+i] < 0 {
+					continue
+				}
+				s := samples[grid[j*cols+i]]
+				if (s[0]-x)*(s[0]-x)+(s[1]-y)*(s[1]-y) < spacing*spacing {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	add(r.Float64()*float64(width), r.Float64()*float64(height))
+
+	for len(active) > 0 {
+
+		k := r.Intn(len(active))
+		s := samples[active[k]]
+
+		found := false
+		for a := 0; a < attempts && !found; a++ {
+			// candidate in the annulus between the spacing and its double
+			angle := 2 * math.Pi * r.Float64()
+			distance := spacing * (1 + r.Float64())
+			x := s[0] + distance*math.Cos(angle)
+			y := s[1] + distance*math.Sin(angle)
+			if fits(x, y) {
+				add(x, y)
+				found = true
+			}
+		}
+
+		if !found {
+			active[k] = active[len(active)-1]
+			active = active[:len(active)-1]
+		}
+	}
+
+	// the samples grow outward from the first one: picking them at random keeps the coverage even
+	points := []Point{}
+	for _, i := range r.Perm(len(samples)) {
+		if len(points) == numSeeds {
+			break
+		}
+		points = append(points, Point{X: int(samples[i][0]), Y: int(samples[i][1])})
+	}
+	return points
+}
+
+/*
+JitteredGrid places the seeds on a square grid, moving each one randomly inside its grid cell (stratified sampling).
+
+The grid has about as many cells as the requested seeds, with the same aspect ratio of the canvas:
+when there are more cells than seeds, the cells left empty are chosen at random
+*/
+type JitteredGrid struct {
+	Jitter float64 // fraction of its grid cell a seed can move in (0 for a regular grid, 1 for the whole cell)
+}
+
+func (JitteredGrid) String() string {
+	return "jittered"
+}
+
+// Generate returns numSeeds positions, one in each of numSeeds cells of the grid
+func (j JitteredGrid) Generate(width int, height int, numSeeds int, r *rand.Rand) []Point {
+
+	if numSeeds == 0 {
+		return []Point{}
+	}
+
+	cols := int(math.Round(math.Sqrt(float64(numSeeds*width) / float64(height))))
+	cols = int(math.Max(1, math.Min(float64(cols), float64(numSeeds))))
+	rows := (numSeeds + cols - 1) / cols
+
+	cellWidth := float64(width) / float64(cols)
+	cellHeight := float64(height) / float64(rows)
+
+	points := make([]Point, numSeeds)
+	for i, cell := range r.Perm(cols * rows)[:numSeeds] {
+		x := (float64(cell%cols) + 0.5 + j.Jitter*(r.Float64()-0.5)) * cellWidth
+		y := (float64(cell/cols) + 0.5 + j.Jitter*(r.Float64()-0.5)) * cellHeight
+		points[i] = Point{
+			X: int(math.Min(x, float64(width-1))),
+			Y: int(math.Min(y, float64(height-1))),
+		}
+	}
+	return points
+}
+
+/*
+HexGrid places the seeds on a hexagonal lattice (the densest packing of the plane),
+whose cells are regular hexagons away from the borders.
+
+The spacing of the lattice is the largest one giving at most the requested number of seeds,
+and its position on the canvas is random
+*/
+type HexGrid struct{}
+
+func (HexGrid) String() string {
+	return "hex"
+}
+
+// Generate returns the points of the lattice, at most numSeeds
+func (HexGrid) Generate(width int, height int, numSeeds int, r *rand.Rand) []Point {
+
+	if numSeeds == 0 {
+		return []Point{}
+	}
+
+	offsetX := r.Float64()
+	offsetY := r.Float64()
+
+	lattice := func(spacing float64) []Point {
+		points := []Point{}
+		rowHeight := spacing * math.Sqrt(3) / 2
+		// the offsets don't push the first point out of a canvas smaller than the spacing
+		for row := 0; ; row++ {
+			y := offsetY*math.Min(rowHeight, float64(height)) + float64(row)*rowHeight
+			if y >= float64(height) {
+				break
+			}
+			// odd rows are shifted by half the spacing
+			shift := math.Mod(offsetX+0.5*float64(row%2), 1) * math.Min(spacing, float64(width))
+			for col := 0; ; col++ {
+				x := shift + float64(col)*spacing
+				if x >= float64(width) {
+					break
+				}
+				points = append(points, Point{X: int(x), Y: int(y)})
+			}
+		}
+		return points
+	}
+
+	// each point of the lattice covers an area of sqrt(3)/2*spacing^2
+	spacing := math.Sqrt(2 * float64(width*height) / (math.Sqrt(3) * float64(numSeeds)))
+	points := lattice(spacing)
+	for len(points) > numSeeds {
+		spacing *= 1.01
+		points = lattice(spacing)
+	}
+	return points
+}
+
+/*
+Halton places the seeds on the Halton sequence with bases 2 and 3, a low-discrepancy sequence:
+the seeds cover the canvas more evenly than random ones, without a regular structure.
+
+The sequence is shifted by a random offset along both axes (wrapping around the borders),
+so that each random seed gives a different set of seeds
+*/
+type Halton struct{}
+
+func (Halton) String() string {
+	return "halton"
+}
+
+// Generate returns the first numSeeds points of the shifted sequence
+func (Halton) Generate(width int, height int, numSeeds int, r *rand.Rand) []Point {
+
+	offsetX := r.Float64()
+	offsetY := r.Float64()
+
+	points := make([]Point, numSeeds)
+	for i := range points {
+		// the sequence starts from 1, as the point 0 is the origin in every base
+		x := math.Mod(radicalInverse(i+1, 2)+offsetX, 1)
+		y := math.Mod(radicalInverse(i+1, 3)+offsetY, 1)
+		points[i] = Point{
+			X: int(math.Min(x*float64(width), float64(width-1))),
+			Y: int(math.Min(y*float64(height), float64(height-1))),
+		}
+	}
+	return points
+}
+
+// radicalInverse mirrors the digits of n in the given base around the radix point (e.g. 6 = 110b becomes 0.011b)
+func radicalInverse(n int, base int) float64 {
+
+	inverse := 0.0
+	f := 1.0 / float64(base)
+	for ; n > 0; n /= base {
+		inverse += float64(n%base) * f
+		f /= float64(base)
+	}
+	return inverse
+}
+
+/*
+Clusters places the seeds in groups, normally distributed around centers chosen uniformly at random.
+
+A zero number of clusters defaults to one every 10 seeds,
+and a zero spread to a twentieth of the shortest side of the canvas
+*/
+type Clusters struct {
+	Count  int     // number of clusters
+	Spread float64 // standard deviation of the distance between the seeds and the center of their cluster (in pixels)
+}
+
+func (Clusters) String() string {
+	return "clusters"
+}
+
+// Generate returns numSeeds positions, spread evenly among the clusters
+func (c Clusters) Generate(width int, height int, numSeeds int, r *rand.Rand) []Point {
+
+	count := c.Count
+	if count <= 0 {
+		count = (numSeeds + 9) / 10
+	}
+	spread := c.Spread
+	if spread <= 0 {
+		spread = math.Min(float64(width), float64(height)) / 20
+	}
+
+	centers := make([][2]float64, count)
+	for i := range centers {
+		centers[i] = [2]float64{r.Float64() * float64(width), r.Float64() * float64(height)}
+	}
+
+	// positions falling outside the canvas are drawn again (a few times, then clamped to the borders)
+	const attempts = 10
+
+	points := make([]Point, numSeeds)
+	for i := range points {
+		center := centers[i%count]
+		var x, y float64
+		for a := 0; a < attempts; a++ {
+			x = center[0] + r.NormFloat64()*spread
+			y = center[1] + r.NormFloat64()*spread
+			if x >= 0 && x < float64(width) && y >= 0 && y < float64(height) {
+				break
+			}
+		}
+		points[i] = Point{
+			X: int(math.Max(0, math.Min(x, float64(width-1)))),
+			Y: int(math.Max(0, math.Min(y, float64(height-1)))),
+		}
+	}
+	return points
+}
@@ -0,0 +1,82 @@
+package voronoi
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// TestSeedGenerators checks that every distribution places the requested number of seeds inside the canvas
+// (at most that number for the hexagonal lattice), also on canvases narrower than the spacing of the seeds
+func TestSeedGenerators(t *testing.T) {
+
+	sizes := [][3]int{{200, 150, 100}, {300, 20, 60}, {1, 50, 5}, {7, 7, 49}, {120, 90, 1}, {50, 50, 0}}
+
+	for _, g := range SeedGenerators() {
+		for _, size := range sizes {
+			width, height, numSeeds := size[0], size[1], size[2]
+			points := g.Generate(width, height, numSeeds, rand.New(rand.NewSource(3)))
+
+			switch g.(type) {
+			case HexGrid, PoissonDisk:
+				if len(points) > numSeeds || (numSeeds > 0 && len(points) == 0) {
+					t.Fatalf("%s, %dx%d: %d seeds instead of at most %d", g, width, height, len(points), numSeeds)
+				}
+			default:
+				if len(points) != numSeeds {
+					t.Fatalf("%s, %dx%d: %d seeds instead of %d", g, width, height, len(points), numSeeds)
+				}
+			}
+			for _, p := range points {
+				if p.X < 0 || p.X >= width || p.Y < 0 || p.Y >= height {
+					t.Fatalf("%s, %dx%d: seed %v out of the canvas", g, width, height, p)
+				}
+			}
+		}
+	}
+}
+
+// TestPoissonDiskSpacing checks that the Poisson-disk seeds keep the minimum spacing (less the rounding to the pixels),
+// and that the default spacing places all the requested seeds
+func TestPoissonDiskSpacing(t *testing.T) {
+
+	for _, spacing := range []float64{0, 6, 15} {
+		g := PoissonDisk{Spacing: spacing}
+		points := g.Generate(200, 150, 150, rand.New(rand.NewSource(8)))
+
+		if spacing == 0 {
+			if len(points) != 150 {
+				t.Fatalf("%d seeds with the default spacing instead of 150", len(points))
+			}
+			spacing = 0.75 * math.Sqrt(200*150/150.0)
+		}
+		for i, p := range points {
+			for _, q := range points[:i] {
+				if d := math.Hypot(float64(p.X-q.X), float64(p.Y-q.Y)); d <= spacing-math.Sqrt2 {
+					t.Fatalf("spacing %g: the seeds %v and %v are %g apart", spacing, p, q, d)
+				}
+			}
+		}
+	}
+}
+
+// TestJitteredGrid checks that the jittered seeds fall in distinct cells of the grid, and that without jitter they are its centers
+func TestJitteredGrid(t *testing.T) {
+
+	// a 10x5 grid on a 200x100 canvas
+	for _, jitter := range []float64{0, 1} {
+		points := JitteredGrid{Jitter: jitter}.Generate(200, 100, 50, rand.New(rand.NewSource(4)))
+
+		cells := map[[2]int]bool{}
+		for _, p := range points {
+			cell := [2]int{p.X / 20, p.Y / 20}
+			if cells[cell] {
+				t.Fatalf("jitter %g: two seeds in the cell %v", jitter, cell)
+			}
+			cells[cell] = true
+			if jitter == 0 && (p.X%20 != 10 || p.Y%20 != 10) {
+				t.Fatalf("the seed %v is not at the center of its cell", p)
+			}
+		}
+	}
+}
@@ -0,0 +1,495 @@
+package voronoi
+
+import (
+	"errors"
+	"math"
+)
+
+// EDT is the engine computing the exact voronoi diagram as a byproduct of
+// the Euclidean distance transform of the seeds (Felzenszwalb and Huttenlocher algorithm).
+//
+// The squared distance is separable: the transform is computed column by column first,
+// finding the nearest seed of each column, and then row by row, as the lower envelope
+// of the parabolas rooted in the pixels of the row.
+// Both phases are linear in the number of pixels, and only need a couple of maps of the canvas size
+// (no precomputed distances matrix).
+//
+// The other metrics are separable as well: their row phase computes the lower envelope
+// of the distance functions with the scheme of Meijster, Roerdink and Hesselink.
+//
+// The power distance is the squared distance shifted by the radius of each seed:
+// both phases compute the lower envelope of parabolas, rooted in the seeds for the columns
+type EDT struct {
+
+	// diagram size (in pixels)
+	width  int
+	height int
+
+	// seed configuration of the diagram
+	numSeeds int     // number of seeds for the diagram
+	seeds    []Point // list of seeds for the diagram
+
+	phase int // next phase of the transform to compute: 0 columns, 1 rows, 2 done
+
+	labels    []int     // index of the nearest seed of each pixel, row by row (-1 if none)
+	offsets   []int     // vertical distance of each pixel from the nearest seed of its column, row by row
+	distances []float64 // distance of each pixel from its nearest seed, row by row
+	squared   []int     // squared euclidean distance of each pixel from the seed of its cell, row by row (-1 if not reached yet)
+
+	config // optional settings (including the random generation of the seeds, see seeding)
+}
+
+// edtInfinity is the vertical distance of the pixels without any seed in their column
+const edtInfinity = math.MaxInt32
+
+// NewEDT creates a new diagram struct computed with the Euclidean distance transform.
+// If explicit seeds are provided through the options, numSeeds is ignored.
+// The transform measures the distance from the nearest seed, so multiplicatively weighted seeds are not supported
+func NewEDT(
+	width int,
+	height int,
+	numSeeds int,
+	opts ...Option,
+) (*EDT, error) {
+
+	c, numSeeds, err := setup(width, height, numSeeds, opts)
+	if err != nil {
+		return nil, err
+	}
+	if c.weighting == Multiplicative {
+		return nil, errors.New("The distance transform doesn't support multiplicatively weighted seeds")
+	}
+
+	return &EDT{
+		width:     width,
+		height:    height,
+		numSeeds:  numSeeds,
+		seeds:     []Point{},
+		labels:    make([]int, width*height),
+		offsets:   make([]int, width*height),
+		distances: make([]float64, width*height),
+		squared:   make([]int, width*height),
+		config:    c,
+	}, nil
+}
+
+// Init initializes the diagram and generates a new set of seeds
+func (e *EDT) Init() {
+	e.restart(generateSeeds(e.width, e.height, e.numSeeds, e.config))
+}
+
+// SetSeeds replaces the seeds of the diagram, and restarts the tessellation
+func (e *EDT) SetSeeds(seeds []Point) error {
+
+	s, err := replaceSeeds(e.width, e.height, seeds, e.config)
+	if err != nil {
+		return err
+	}
+	e.restart(s)
+
+	return nil
+}
+
+// restart starts the transform from scratch with the given seeds
+func (e *EDT) restart(seeds []Point) {
+
+	e.seeds = seeds
+	e.phase = 0
+
+	for pos := range e.labels {
+		e.labels[pos] = -1
+		e.offsets[pos] = edtInfinity
+		e.distances[pos] = 0
+		e.squared[pos] = -1
+	}
+	for i, s := range e.seeds {
+		pos := s.Y*e.width + s.X
+		distance := e.config.distance(s, 0, 0)
+
+		// with duplicated seeds, the pixel belongs to the first one (or to the largest one, with power distances)
+		if e.labels[pos] < 0 || distance < e.distances[pos] {
+			e.labels[pos] = i
+			e.offsets[pos] = 0
+			e.distances[pos] = distance
+			e.squared[pos] = 0
+		}
+	}
+}
+
+// Tessellate computes the voronoi diagram.
+// If hideIterations is false, a single phase of the transform (columns or rows) is computed at each call
+func (e *EDT) Tessellate(hideIterations bool) error {
+
+	for e.phase < 2 {
+		if e.config.weighting == Power {
+			e.transformWithPower()
+		} else if e.phase == 0 {
+			e.transformColumns()
+		} else if _, ok := e.config.metric.(Euclidean); ok {
+			e.transformRows()
+		} else {
+			e.transformRowsWithMetric()
+		}
+		e.phase++
+
+		if !hideIterations {
+			// this breaks the computation to the current state of the tessellation,
+			// useful to show the evolution of the diagram
+			break
+		}
+	}
+
+	return nil
+}
+
+// Done reports whether the tessellation is complete, i.e. both phases of the transform are done
+func (e *EDT) Done() bool {
+	return e.phase == 2
+}
+
+// transformColumns finds, for each pixel, the nearest seed in the same column,
+// scanning each column downward and then upward (twice around the column, on a toroidal canvas)
+func (e *EDT) transformColumns() {
+
+	span := e.height
+	if e.config.toroidal {
+		span = 2 * e.height
+	}
+
+	for x := 0; x < e.width; x++ {
+
+		// nearest seed above the pixel
+		nearest := -1
+		for i := 0; i < span; i++ {
+			pos := (i%e.height)*e.width + x
+			if e.offsets[pos] == 0 {
+				nearest = i
+			} else if nearest >= 0 && i-nearest < e.offsets[pos] {
+				e.labels[pos] = e.labels[(nearest%e.height)*e.width+x]
+				e.offsets[pos] = i - nearest
+			}
+		}
+
+		// nearest seed below the pixel, if closer
+		nearest = -1
+		for i := span - 1; i >= 0; i-- {
+			pos := (i%e.height)*e.width + x
+			if e.offsets[pos] == 0 {
+				nearest = i
+			} else if nearest >= 0 && nearest-i < e.offsets[pos] {
+				e.labels[pos] = e.labels[(nearest%e.height)*e.width+x]
+				e.offsets[pos] = nearest - i
+			}
+		}
+	}
+
+	for pos, offset := range e.offsets {
+		if offset != edtInfinity {
+			e.distances[pos] = e.config.metric.Distance(0, offset)
+			e.squared[pos] = offset * offset
+		}
+	}
+}
+
+// extent returns the length of the domain of the lower envelopes computed over a line of pixels,
+// and the position of the line in the domain.
+// On a toroidal canvas the line is repeated three times, so that the envelope reaches the seeds across the borders
+func (e *EDT) extent(length int) (int, int) {
+	if e.config.toroidal {
+		return 3 * length, length
+	}
+	return length, 0
+}
+
+// transformRows computes, for each row, the lower envelope of the parabolas (x-q)^2 + f(q),
+// where f(q) is the squared distance of the pixel q from the nearest seed of its column
+func (e *EDT) transformRows() {
+
+	n, base := e.extent(e.width)
+	f := make([]int, n)
+	fLabels := make([]int, n)
+	v := make([]int, n)       // pixels whose parabolas form the lower envelope
+	z := make([]float64, n+1) // boundaries between the parabolas of the lower envelope
+
+	for y := 0; y < e.height; y++ {
+		offsets := e.offsets[y*e.width : (y+1)*e.width]
+		rowLabels := e.labels[y*e.width : (y+1)*e.width]
+		for q := range f {
+			offset := offsets[q%e.width]
+			f[q] = edtInfinity
+			if offset != edtInfinity {
+				f[q] = offset * offset
+			}
+			fLabels[q] = rowLabels[q%e.width]
+		}
+
+		// build the lower envelope, ignoring the pixels without any seed in their column
+		k := -1
+		for q := 0; q < n; q++ {
+			if f[q] == edtInfinity {
+				continue
+			}
+			if k < 0 {
+				k = 0
+				v[0] = q
+				z[0] = math.Inf(-1)
+				z[1] = math.Inf(1)
+				continue
+			}
+
+			s := e.intersection(f, q, v[k])
+			for s <= z[k] {
+				k--
+				s = e.intersection(f, q, v[k])
+			}
+			k++
+			v[k] = q
+			z[k] = s
+			z[k+1] = math.Inf(1)
+		}
+
+		// no seed in reach of the row (it happens only without seeds)
+		if k < 0 {
+			continue
+		}
+
+		// read the distances from the lower envelope
+		k = 0
+		for x := base; x < base+e.width; x++ {
+			for z[k+1] < float64(x) {
+				k++
+			}
+			squared := (x-v[k])*(x-v[k]) + f[v[k]]
+			e.squared[y*e.width+x-base] = squared
+			e.distances[y*e.width+x-base] = math.Sqrt(float64(squared))
+			rowLabels[x-base] = fLabels[v[k]]
+		}
+	}
+}
+
+// intersection computes the abscissa where the parabolas rooted in the pixels q and p (q > p) intersect
+func (e *EDT) intersection(f []int, q int, p int) float64 {
+	return float64((f[q]+q*q)-(f[p]+p*p)) / float64(2*q-2*p)
+}
+
+// transformRowsWithMetric computes, for each row, the lower envelope of the functions
+// metric(x-q, offset(q)), where offset(q) is the vertical distance of the pixel q from the nearest seed of its column.
+// Each function of the envelope rules over a range of pixels: the range boundaries are found by bisection
+func (e *EDT) transformRowsWithMetric() {
+
+	n, base := e.extent(e.width)
+	s := make([]int, n) // pixels whose functions form the lower envelope
+	t := make([]int, n) // first pixel of the range of each function of the envelope
+	fLabels := make([]int, n)
+
+	for y := 0; y < e.height; y++ {
+		offsets := e.offsets[y*e.width : (y+1)*e.width]
+		rowLabels := e.labels[y*e.width : (y+1)*e.width]
+		for q := range fLabels {
+			fLabels[q] = rowLabels[q%e.width]
+		}
+
+		f := func(x int, q int) float64 {
+			return e.config.metric.Distance(x-q, offsets[q%e.width])
+		}
+
+		// build the lower envelope, ignoring the pixels without any seed in their column
+		k := -1
+		for u := 0; u < n; u++ {
+			if offsets[u%e.width] == edtInfinity {
+				continue
+			}
+
+			// discard the functions whose range is entirely dominated by the new one
+			for k >= 0 && f(t[k], s[k]) > f(t[k], u) {
+				k--
+			}
+
+			if k < 0 {
+				k = 0
+				s[0] = u
+				t[0] = 0
+				continue
+			}
+
+			// find the last pixel where the function of s[k] is not worse than the new one
+			low := t[k]
+			high := n
+			for high-low > 1 {
+				mid := (low + high) / 2
+				if f(mid, s[k]) <= f(mid, u) {
+					low = mid
+				} else {
+					high = mid
+				}
+			}
+			if high < n {
+				k++
+				s[k] = u
+				t[k] = high
+			}
+		}
+
+		// no seed in reach of the row (it happens only without seeds)
+		if k < 0 {
+			continue
+		}
+
+		// read the distances from the lower envelope
+		for x := base + e.width - 1; x >= base; x-- {
+			for t[k] > x {
+				k--
+			}
+			e.distances[y*e.width+x-base] = f(x, s[k])
+			rowLabels[x-base] = fLabels[s[k]]
+		}
+	}
+
+	e.measureSquared()
+}
+
+// transformWithPower computes the current phase of the transform with the power distances.
+// The columns phase computes the lower envelope of the parabolas (y-s)^2 - r^2 rooted in the seeds of each column,
+// then the rows phase computes the lower envelope of the parabolas (x-q)^2 + f(q) rooted in the pixels of each row,
+// where f(q) is the result of the columns phase
+func (e *EDT) transformWithPower() {
+
+	n, _ := e.extent(maxInt(e.width, e.height))
+	f := make([]float64, n)
+	fLabels := make([]int, n)
+	distances := make([]float64, n)
+	labels := make([]int, n)
+	v := make([]int, n)
+	z := make([]float64, n+1)
+
+	// positions of the pixels of each line: columns in the first phase, rows in the second one
+	lines, length, stride, step := e.width, e.height, 1, e.width
+	if e.phase == 1 {
+		lines, length, stride, step = e.height, e.width, e.width, 1
+	}
+	n, base := e.extent(length)
+
+	for line := 0; line < lines; line++ {
+		for i := 0; i < n; i++ {
+			pos := line*stride + (i%length)*step
+			f[i] = math.Inf(1)
+			fLabels[i] = e.labels[pos]
+
+			// in the first phase only the seeds are roots of the parabolas
+			if e.labels[pos] >= 0 && (e.phase == 1 || e.offsets[pos] == 0) {
+				f[i] = e.distances[pos]
+			}
+		}
+
+		if !lowerEnvelope(f[:n], fLabels, distances, labels, v, z) {
+			continue
+		}
+
+		for i := base; i < base+length; i++ {
+			pos := line*stride + (i-base)*step
+			e.labels[pos] = labels[i]
+			e.distances[pos] = distances[i]
+		}
+	}
+
+	// the final distances are measured again from the seeds, avoiding the rounding errors of the two phases
+	if e.phase == 1 {
+		for pos, l := range e.labels {
+			if l < 0 {
+				continue
+			}
+			s := e.seeds[l]
+			e.distances[pos] = e.config.distance(s, e.config.wrap(pos%e.width-s.X, e.width), e.config.wrap(pos/e.width-s.Y, e.height))
+		}
+	}
+
+	e.measureSquared()
+}
+
+// measureSquared measures the squared euclidean distance of each pixel from the seed of its cell,
+// when the transform computes a different distance (another metric, or the power distance)
+func (e *EDT) measureSquared() {
+	for pos, l := range e.labels {
+		if l < 0 {
+			continue
+		}
+		s := e.seeds[l]
+		dx := e.config.wrap(pos%e.width-s.X, e.width)
+		dy := e.config.wrap(pos/e.width-s.Y, e.height)
+		e.squared[pos] = dx*dx + dy*dy
+	}
+}
+
+// lowerEnvelope computes the lower envelope of the parabolas (x-q)^2 + f(q) over a line of pixels,
+// ignoring the pixels q where f is infinite: for each pixel x, it writes the value of the envelope in distances,
+// and the label of the pixel the lowest parabola is rooted in.
+// It returns false if all the pixels are ignored. v and z are working buffers
+func lowerEnvelope(f []float64, fLabels []int, distances []float64, labels []int, v []int, z []float64) bool {
+
+	intersection := func(q int, p int) float64 {
+		return ((f[q] + float64(q*q)) - (f[p] + float64(p*p))) / float64(2*q-2*p)
+	}
+
+	k := -1
+	for q := range f {
+		if math.IsInf(f[q], 1) {
+			continue
+		}
+		if k < 0 {
+			k = 0
+			v[0] = q
+			z[0] = math.Inf(-1)
+			z[1] = math.Inf(1)
+			continue
+		}
+
+		s := intersection(q, v[k])
+		for s <= z[k] {
+			k--
+			s = intersection(q, v[k])
+		}
+		k++
+		v[k] = q
+		z[k] = s
+		z[k+1] = math.Inf(1)
+	}
+
+	if k < 0 {
+		return false
+	}
+
+	k = 0
+	for x := range f {
+		for z[k+1] < float64(x) {
+			k++
+		}
+		distances[x] = float64((x-v[k])*(x-v[k])) + f[v[k]]
+		labels[x] = fLabels[v[k]]
+	}
+	return true
+}
+
+// Diagram returns a snapshot of the current state of the tessellation.
+// Its distances are the distance transform of the seeds
+func (e *EDT) Diagram() *Diagram {
+
+	d := newDiagram(e.width, e.height, e.seeds, e.config)
+	copy(d.Labels, e.labels)
+	copy(d.Distances, e.distances)
+
+	return d
+}
+
+// SquaredDistances returns the squared euclidean distance of each pixel from the seed of its cell, row by row
+// (-1 for the pixels not reached yet). Unlike the distances of the Diagram, they are exact integers:
+// with the euclidean metric and no weights, they are the squared distance map computed by the transform
+func (e *EDT) SquaredDistances() []int {
+	return append([]int{}, e.squared...)
+}
+
+// ToPixels generates the byte array containing the information to render the diagram.
+// Each row of the canvas is concatenated to obtain a one-dimensional array.
+// Each pixel is represented by 4 bytes, representing the Red, Green, Blue and Alpha info.
+func (e *EDT) ToPixels() []byte {
+	return e.Diagram().ToPixels()
+}
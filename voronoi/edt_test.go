@@ -0,0 +1,53 @@
+package voronoi
+
+import (
+	"math"
+	"testing"
+)
+
+// TestSquaredDistances checks that the squared distance map of the transform holds, for each pixel,
+// the squared euclidean distance from the nearest seed (from the seed of its cell, with the other metrics and weights)
+func TestSquaredDistances(t *testing.T) {
+
+	cases := []struct {
+		name      string
+		opts      []Option
+		euclidean bool // true if the transform measures the euclidean distances
+	}{
+		{"euclidean", nil, true},
+		{"toroidal", []Option{WithToroidal()}, true},
+		{"manhattan", []Option{WithMetric(Manhattan{})}, false},
+		{"power", []Option{WithWeighting(Power), WithToroidal()}, false},
+	}
+
+	for _, c := range cases {
+		e, err := NewEDT(90, 70, 30, append([]Option{WithRandSeed(4)}, c.opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Init()
+		e.Tessellate(true)
+		d := e.Diagram()
+		squared := e.SquaredDistances()
+
+		for pos, label := range d.Labels {
+			x, y := pos%d.Width, pos/d.Width
+
+			// the nearest seed with the squared euclidean distance, or the seed of the cell
+			expected := math.MaxInt64
+			for i, s := range d.Seeds {
+				dx, dy := e.config.wrap(x-s.X, d.Width), e.config.wrap(y-s.Y, d.Height)
+				if c.euclidean || i == label {
+					expected = minInt(expected, dx*dx+dy*dy)
+				}
+			}
+
+			if squared[pos] != expected {
+				t.Fatalf("%s: (%d, %d) has squared distance %d instead of %d", c.name, x, y, squared[pos], expected)
+			}
+			if c.euclidean && d.Distances[pos] != math.Sqrt(float64(expected)) {
+				t.Fatalf("%s: (%d, %d) has distance %g instead of the square root of %d", c.name, x, y, d.Distances[pos], expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package voronoi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Engine is a backend able to compute a voronoi diagram
+type Engine interface {
+
+	// Init initializes the diagram and generates a new set of seeds
+	Init()
+
+	// RandSeed returns the random seed the seeds are generated from
+	RandSeed() int64
+
+	// SetRandSeed changes the random seed the seeds are generated from, starting from the next Init
+	SetRandSeed(seed int64)
+
+	// SeedGenerator returns the distribution of the random seeds
+	SeedGenerator() SeedGenerator
+
+	// SetSeedGenerator changes the distribution of the random seeds, starting from the next Init
+	SetSeedGenerator(g SeedGenerator)
+
+	// SetSeeds replaces the seeds of the diagram, and restarts the tessellation
+	SetSeeds(seeds []Point) error
+
+	// Tessellate computes the diagram.
+	// If hideIterations is false, the computation may stop at an intermediate state,
+	// and subsequent calls continue from there
+	Tessellate(hideIterations bool) error
+
+	// Done reports whether the tessellation is complete
+	Done() bool
+
+	// ToPixels generates the RGBA byte array containing the information to render the diagram
+	ToPixels() []byte
+
+	// Diagram returns a snapshot of the current state of the tessellation
+	Diagram() *Diagram
+}
+
+// SeedEditor is implemented by the engines able to edit a single seed without restarting the tessellation,
+// recomputing only the pixels around it
+type SeedEditor interface {
+
+	// AddSeed adds a seed to the diagram, with the next index
+	AddSeed(seed Point) error
+
+	// RemoveSeed removes a seed (given its index): the seeds after it shift down by one index
+	RemoveSeed(index int) error
+
+	// MoveSeed moves a seed (given its index) to the pixel (x, y)
+	MoveSeed(index int, x int, y int) error
+}
+
+// Algorithm identifies the backend used to compute a diagram
+type Algorithm string
+
+const (
+	// AlgorithmWavefront grows the cells from the seeds, one layer of pixels at a time (approximated)
+	AlgorithmWavefront Algorithm = "wavefront"
+
+	// AlgorithmFortune computes the exact vector diagram with the Fortune's sweep-line algorithm
+	AlgorithmFortune Algorithm = "fortune"
+
+	// AlgorithmBruteForce computes the exact diagram finding the nearest seed of each pixel (slow, used as reference)
+	AlgorithmBruteForce Algorithm = "bruteforce"
+
+	// AlgorithmJFA computes the diagram with the parallel Jump Flooding Algorithm (approximated)
+	AlgorithmJFA Algorithm = "jfa"
+
+	// AlgorithmEDT computes the exact diagram and distance field with the linear-time Euclidean distance transform
+	AlgorithmEDT Algorithm = "edt"
+)
+
+// New creates a diagram computed with the given algorithm.
+// If explicit seeds are provided through the options, numSeeds is ignored
+func New(
+	algorithm Algorithm,
+	width int,
+	height int,
+	numSeeds int,
+	opts ...Option,
+) (Engine, error) {
+
+	switch algorithm {
+	case AlgorithmWavefront:
+		return NewVoronoi(width, height, numSeeds, opts...)
+	case AlgorithmFortune:
+		return NewFortune(width, height, numSeeds, opts...)
+	case AlgorithmBruteForce:
+		return NewBruteForce(width, height, numSeeds, opts...)
+	case AlgorithmJFA:
+		return NewJFA(width, height, numSeeds, opts...)
+	case AlgorithmEDT:
+		return NewEDT(width, height, numSeeds, opts...)
+	default:
+		return nil, fmt.Errorf("Unknown algorithm %q", algorithm)
+	}
+}
+
+// ParseAlgorithm returns the algorithm with the given name (wavefront, fortune, bruteforce, jfa or edt)
+func ParseAlgorithm(name string) (Algorithm, error) {
+
+	algorithm := Algorithm(strings.ToLower(name))
+	switch algorithm {
+	case AlgorithmWavefront, AlgorithmFortune, AlgorithmBruteForce, AlgorithmJFA, AlgorithmEDT:
+		return algorithm, nil
+	default:
+		return "", fmt.Errorf("Unknown algorithm %q", name)
+	}
+}
@@ -0,0 +1,142 @@
+package voronoi
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestAccuracy compares the diagram of every backend with the one of the brute force, for each metric, weighting and topology
+// the backend supports. The exact backends must assign every pixel to one of its nearest seeds,
+// while the approximated ones (the Jump Flooding Algorithm, and the wavefront without weights) may miss up to 1% of the pixels
+func TestAccuracy(t *testing.T) {
+
+	metrics := []Metric{Euclidean{}, Manhattan{}, Chebyshev{}, Minkowski{P: 3}}
+	weightings := []Weighting{Unweighted, Multiplicative, Power}
+	algorithms := []Algorithm{AlgorithmWavefront, AlgorithmFortune, AlgorithmJFA, AlgorithmEDT}
+
+	for _, metric := range metrics {
+		for _, weighting := range weightings {
+			for _, toroidal := range []bool{false, true} {
+
+				opts := []Option{WithMetric(metric), WithWeighting(weighting)}
+				if toroidal {
+					opts = append(opts, WithToroidal())
+				}
+				if _, ok := metric.(Euclidean); weighting == Power && !ok {
+					continue
+				}
+
+				for _, algorithm := range algorithms {
+					if !supports(algorithm, metric, weighting) {
+						continue
+					}
+
+					name := fmt.Sprintf("%s %s %v toroidal=%v", algorithm, metric, weighting, toroidal)
+					t.Run(name, func(t *testing.T) {
+
+						for trial := int64(0); trial < 4; trial++ {
+							reference := tessellate(t, AlgorithmBruteForce, 0, append([]Option{WithRandSeed(trial)}, opts...))
+							d := tessellate(t, algorithm, 0, append([]Option{WithSeeds(reference.Seeds)}, opts...))
+
+							c, err := Compare(d, reference)
+							if err != nil {
+								t.Fatal(err)
+							}
+							if c.Unassigned > 0 {
+								t.Fatalf("trial %d: %v", trial, c)
+							}
+							if exact(algorithm, weighting) && len(c.Mismatches) > 0 || c.Percentage() > 1 {
+								t.Fatalf("trial %d: %v, the first mismatch at %v", trial, c, c.Mismatches[0])
+							}
+						}
+					})
+				}
+			}
+		}
+	}
+}
+
+// supports reports whether an algorithm can compute the diagrams with the given metric and weighting
+func supports(algorithm Algorithm, metric Metric, weighting Weighting) bool {
+
+	_, euclidean := metric.(Euclidean)
+	switch algorithm {
+	case AlgorithmFortune:
+		return euclidean && weighting == Unweighted
+	case AlgorithmEDT:
+		return weighting != Multiplicative
+	default:
+		return true
+	}
+}
+
+// exact reports whether an algorithm computes the exact diagrams with the given weighting
+func exact(algorithm Algorithm, weighting Weighting) bool {
+
+	switch algorithm {
+	case AlgorithmJFA:
+		return false
+	case AlgorithmWavefront:
+		return weighting != Unweighted
+	default:
+		return true
+	}
+}
+
+// tessellate computes the whole 90*70 diagram of 30 seeds (unless set by the options) with the given algorithm
+func tessellate(t *testing.T, algorithm Algorithm, workers int, opts []Option) *Diagram {
+
+	e, err := New(algorithm, 90, 70, 30, append(opts, WithWorkers(workers))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Init()
+	for !e.Done() {
+		if err := e.Tessellate(true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return e.Diagram()
+}
+
+// TestNarrowCanvas computes the diagrams of canvases one pixel wide or high, and of canvases much longer than wide,
+// with every backend: all the pixels must be assigned, to one of their nearest seeds with the exact backends
+func TestNarrowCanvas(t *testing.T) {
+
+	sizes := [][3]int{{1, 50, 4}, {50, 1, 4}, {1, 1, 1}, {2, 1, 2}, {10, 50, 3}, {60, 3, 5}}
+	algorithms := []Algorithm{AlgorithmWavefront, AlgorithmFortune, AlgorithmJFA, AlgorithmEDT}
+
+	for _, size := range sizes {
+		for _, toroidal := range []bool{false, true} {
+			opts := []Option{WithRandSeed(2)}
+			if toroidal {
+				opts = append(opts, WithToroidal())
+			}
+
+			diagram := func(algorithm Algorithm, opts []Option) *Diagram {
+				e, err := New(algorithm, size[0], size[1], size[2], opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				e.Init()
+				for !e.Done() {
+					if err := e.Tessellate(true); err != nil {
+						t.Fatal(err)
+					}
+				}
+				return e.Diagram()
+			}
+
+			reference := diagram(AlgorithmBruteForce, opts)
+			for _, algorithm := range algorithms {
+				c, err := Compare(diagram(algorithm, append([]Option{WithSeeds(reference.Seeds)}, opts...)), reference)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if c.Unassigned > 0 || exact(algorithm, Unweighted) && len(c.Mismatches) > 0 {
+					t.Fatalf("%s, %dx%d, toroidal=%v: %v", algorithm, size[0], size[1], toroidal, c)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package voronoi
+
+import (
+	"errors"
+	"math"
+)
+
+// Fortune is the engine computing the exact voronoi diagram with the Fortune's sweep-line algorithm.
+// Besides the pixels of the canvas, it exposes the vector representation of the diagram as a DCEL
+type Fortune struct {
+
+	// diagram size (in pixels)
+	width  int
+	height int
+
+	// seed configuration of the diagram
+	numSeeds int     // number of seeds for the diagram
+	seeds    []Point // list of seeds for the diagram
+
+	dcel    *DCEL    // vector representation of the diagram (nil until the tessellation is computed)
+	diagram *Diagram // raster representation of the diagram (nil until the tessellation is computed)
+
+	config // optional settings (including the random generation of the seeds, see seeding)
+}
+
+// NewFortune creates a new diagram struct computed with the Fortune's algorithm.
+// If explicit seeds are provided through the options, numSeeds is ignored.
+// The geometry of the algorithm only works with the euclidean metric and unweighted seeds
+func NewFortune(
+	width int,
+	height int,
+	numSeeds int,
+	opts ...Option,
+) (*Fortune, error) {
+
+	c, numSeeds, err := setup(width, height, numSeeds, opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := c.metric.(Euclidean); !ok {
+		return nil, errors.New("The Fortune algorithm supports only the euclidean metric")
+	}
+	if c.weighting != Unweighted {
+		return nil, errors.New("The Fortune algorithm doesn't support weighted seeds")
+	}
+
+	return &Fortune{
+		width:    width,
+		height:   height,
+		numSeeds: numSeeds,
+		seeds:    []Point{},
+		config:   c,
+	}, nil
+}
+
+// Init initializes the diagram and generates a new set of seeds
+func (f *Fortune) Init() {
+	f.seeds = generateSeeds(f.width, f.height, f.numSeeds, f.config)
+	f.dcel = nil
+	f.diagram = nil
+}
+
+// SetSeeds replaces the seeds of the diagram, and restarts the tessellation
+func (f *Fortune) SetSeeds(seeds []Point) error {
+
+	s, err := replaceSeeds(f.width, f.height, seeds, f.config)
+	if err != nil {
+		return err
+	}
+	f.seeds = s
+	f.dcel = nil
+	f.diagram = nil
+
+	return nil
+}
+
+// Tessellate computes the voronoi diagram.
+// The sweep has no meaningful intermediate state to show,
+// so the whole diagram is computed at the first call regardless of hideIterations
+func (f *Fortune) Tessellate(hideIterations bool) error {
+
+	if f.dcel != nil {
+		return nil
+	}
+
+	sites := []*site{}
+	for i, s := range f.seeds {
+		sites = append(sites, &site{x: float64(s.X), y: float64(s.Y), index: i})
+	}
+	numCells := len(f.seeds)
+
+	// on a toroidal canvas, the seeds are copied all around the canvas:
+	// the cells of the copies are the pieces of the cells wrapping around the borders
+	if f.config.toroidal {
+		for copyX := -1; copyX <= 1; copyX++ {
+			for copyY := -1; copyY <= 1; copyY++ {
+				if copyX == 0 && copyY == 0 {
+					continue
+				}
+				for i, s := range f.seeds {
+					x := float64(s.X + copyX*f.width)
+					y := float64(s.Y + copyY*f.height)
+
+					// the copies farther than half the canvas can't be the nearest ones of any pixel
+					if x < -float64(f.width)/2 || x > 1.5*float64(f.width) || y < -float64(f.height)/2 || y > 1.5*float64(f.height) {
+						continue
+					}
+					sites = append(sites, &site{x: x, y: y, index: numCells + i})
+				}
+				numCells += len(f.seeds)
+			}
+		}
+	}
+
+	sw := computeSweep(sites, numCells, bbox{
+		xl: 0,
+		xr: float64(f.width),
+		yt: 0,
+		yb: float64(f.height),
+	})
+
+	f.dcel = newDCEL(sw)
+	for _, cell := range f.dcel.Cells {
+		cell.Seed %= len(f.seeds)
+	}
+	f.diagram = f.rasterize()
+
+	return nil
+}
+
+// Done reports whether the tessellation is complete
+func (f *Fortune) Done() bool {
+	return f.dcel != nil
+}
+
+// DCEL returns the vector representation of the diagram (nil if the tessellation is not computed yet)
+func (f *Fortune) DCEL() *DCEL {
+	return f.dcel
+}
+
+// Diagram returns a snapshot of the current state of the tessellation
+func (f *Fortune) Diagram() *Diagram {
+
+	d := newDiagram(f.width, f.height, f.seeds, f.config)
+	if f.diagram != nil {
+		copy(d.Labels, f.diagram.Labels)
+		copy(d.Distances, f.diagram.Distances)
+	}
+
+	return d
+}
+
+// ToPixels generates the byte array containing the information to render the diagram.
+// Each row of the canvas is concatenated to obtain a one-dimensional array.
+// Each pixel is represented by 4 bytes, representing the Red, Green, Blue and Alpha info.
+func (f *Fortune) ToPixels() []byte {
+	if f.diagram == nil {
+		return newDiagram(f.width, f.height, f.seeds, f.config).ToPixels()
+	}
+	return f.diagram.ToPixels()
+}
+
+// rasterize assigns each pixel of the canvas to the cell whose polygon contains it.
+// Cells are convex, so each row of pixels crosses a cell in a single span
+func (f *Fortune) rasterize() *Diagram {
+
+	d := newDiagram(f.width, f.height, f.seeds, f.config)
+
+	for _, cell := range f.dcel.Cells {
+		polygon := cell.Polygon()
+		if len(polygon) == 0 {
+			continue
+		}
+
+		top := math.Inf(1)
+		bottom := math.Inf(-1)
+		for _, v := range polygon {
+			top = math.Min(top, v.Y)
+			bottom = math.Max(bottom, v.Y)
+		}
+
+		for y := maxInt(0, int(math.Ceil(top-epsilon))); y <= minInt(f.height-1, int(math.Floor(bottom+epsilon))); y++ {
+
+			// find the span of the row inside the polygon
+			left := math.Inf(1)
+			right := math.Inf(-1)
+			for i, a := range polygon {
+				b := polygon[(i+1)%len(polygon)]
+				fy := float64(y)
+
+				if (fy < a.Y-epsilon && fy < b.Y-epsilon) || (fy > a.Y+epsilon && fy > b.Y+epsilon) {
+					continue
+				}
+				if math.Abs(a.Y-b.Y) < epsilon {
+					left = math.Min(left, math.Min(a.X, b.X))
+					right = math.Max(right, math.Max(a.X, b.X))
+					continue
+				}
+				x := a.X + (fy-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+				left = math.Min(left, x)
+				right = math.Max(right, x)
+			}
+			if left > right {
+				continue
+			}
+
+			for x := maxInt(0, int(math.Ceil(left-epsilon))); x <= minInt(f.width-1, int(math.Floor(right+epsilon))); x++ {
+				f.assign(d, x, y, cell.Seed)
+			}
+		}
+	}
+
+	// the pixels missed because of rounding errors (if any) get the nearest seed
+	for pos, l := range d.Labels {
+		if l < 0 {
+			for i := range f.seeds {
+				f.assign(d, pos%f.width, pos/f.width, i)
+			}
+		}
+	}
+
+	return d
+}
+
+// assign gives the pixel to the seed, unless the pixel already belongs to a closer one.
+// The pixels laying on the border between two cells are shared by both polygons
+func (f *Fortune) assign(d *Diagram, x int, y int, seedIndex int) {
+
+	seed := f.seeds[seedIndex]
+	distance := f.config.distance(seed, f.config.wrap(x-seed.X, f.width), f.config.wrap(y-seed.Y, f.height))
+	pos := y*f.width + x
+
+	if d.Labels[pos] < 0 || distance < d.Distances[pos] {
+		d.Labels[pos] = seedIndex
+		d.Distances[pos] = distance
+	}
+}
+
+// minInt returns the smaller of two ints
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// maxInt returns the larger of two ints
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
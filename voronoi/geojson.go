@@ -0,0 +1,204 @@
+package voronoi
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+)
+
+// Affine is an affine transform from the coordinates of the canvas (in pixels) to the ones of a map:
+//
+//	x' = A*x + B*y + C
+//	y' = D*x + E*y + F
+type Affine struct {
+	A, B, C float64
+	D, E, F float64
+}
+
+// BoundsTransform returns the transform mapping a width*height canvas to a longitude/latitude bounding box:
+// the top left corner of the canvas goes to (west, north), the bottom right one to (east, south)
+func BoundsTransform(width int, height int, west float64, south float64, east float64, north float64) Affine {
+	return Affine{
+		A: (east - west) / float64(width),
+		C: west,
+		E: -(north - south) / float64(height),
+		F: north,
+	}
+}
+
+// Apply transforms a point
+func (t Affine) Apply(v Vertex) Vertex {
+	return Vertex{
+		X: t.A*v.X + t.B*v.Y + t.C,
+		Y: t.D*v.X + t.E*v.Y + t.F,
+	}
+}
+
+// scale is the factor the transform multiplies the areas by
+func (t Affine) scale() float64 {
+	return math.Abs(t.A*t.E - t.B*t.D)
+}
+
+// GeoJSONOptions customizes the GeoJSON export of a diagram
+type GeoJSONOptions struct {
+	Transform *Affine // transform from the pixels to the coordinates of the map (nil to keep the pixels)
+	Tolerance float64 // maximum distance between the simplified outlines and the borders of the pixels (see Polygons)
+}
+
+// geoFeature is a cell in a GeoJSON document
+type geoFeature struct {
+	Type       string        `json:"type"`
+	ID         int           `json:"id"`
+	Geometry   *geoGeometry  `json:"geometry"`
+	Properties geoProperties `json:"properties"`
+}
+
+// geoGeometry is the outline of a cell in a GeoJSON document:
+// the coordinates of a Polygon, or of a MultiPolygon (a list of polygons) when the cell is split in several pieces
+type geoGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// geoProperties describes a cell in a GeoJSON document
+type geoProperties struct {
+	Seed      int     `json:"seed"`      // index of the seed
+	Label     string  `json:"label"`     // label of the seed
+	Area      float64 `json:"area"`      // area of the cell, in the units of the map (measured on the pixels, before the simplification)
+	Neighbors []int   `json:"neighbors"` // indexes of the seeds whose cells share an edge with this cell
+}
+
+/*
+WriteGeoJSON exports the cells of the diagram as a GeoJSON FeatureCollection, ready to be loaded by a GIS (e.g. QGIS)
+
+Each cell is a feature with the index of its seed as id, and a Polygon geometry
+(a MultiPolygon if the cell is split in several pieces, null if the cell is empty).
+Its properties are the index of the seed, its label, the area of the cell and the indexes of the neighboring cells.
+As required by RFC 7946, the outer rings are counterclockwise and the holes clockwise, after the transform
+*/
+func (d *Diagram) WriteGeoJSON(w io.Writer, o GeoJSONOptions) error {
+
+	transform := Affine{A: 1, E: 1}
+	if o.Transform != nil {
+		transform = *o.Transform
+	}
+
+	_, sizes := d.centroids()
+	neighbors := d.Neighbors()
+
+	features := []geoFeature{}
+	for _, p := range d.Polygons(o.Tolerance) {
+
+		feature := geoFeature{
+			Type: "Feature",
+			ID:   p.Seed,
+			Properties: geoProperties{
+				Seed:      p.Seed,
+				Label:     d.Seeds[p.Seed].Label,
+				Area:      float64(sizes[p.Seed]) * transform.scale(),
+				Neighbors: neighbors[p.Seed],
+			},
+		}
+
+		polygons := groupRings(p.Rings)
+		coordinates := [][][][2]float64{}
+		for _, rings := range polygons {
+			polygon := [][][2]float64{}
+			for i, ring := range rings {
+				polygon = append(polygon, geoRing(ring, transform, i == 0))
+			}
+			coordinates = append(coordinates, polygon)
+		}
+
+		switch len(coordinates) {
+		case 0:
+		case 1:
+			feature.Geometry = &geoGeometry{Type: "Polygon", Coordinates: coordinates[0]}
+		default:
+			feature.Geometry = &geoGeometry{Type: "MultiPolygon", Coordinates: coordinates}
+		}
+		features = append(features, feature)
+	}
+
+	return json.NewEncoder(w).Encode(struct {
+		Type     string       `json:"type"`
+		Features []geoFeature `json:"features"`
+	}{
+		Type:     "FeatureCollection",
+		Features: features,
+	})
+}
+
+// groupRings splits the rings of a cell in polygons: each outer boundary followed by the holes inside it
+func groupRings(rings [][]Vertex) [][][]Vertex {
+
+	polygons := [][][]Vertex{}
+	holes := [][]Vertex{}
+	for _, ring := range rings {
+		if ringArea(ring) > 0 {
+			polygons = append(polygons, [][]Vertex{ring})
+		} else {
+			holes = append(holes, ring)
+		}
+	}
+
+	for _, hole := range holes {
+
+		// the midpoint of an edge of the hole can't lay on the outline of the other rings,
+		// as the vertices of the rings are the corners of the pixels
+		probe := Vertex{X: (hole[0].X + hole[1].X) / 2, Y: (hole[0].Y + hole[1].Y) / 2}
+
+		// the hole belongs to the smallest outer boundary around it
+		owner := -1
+		for i, polygon := range polygons {
+			if !insideRing(probe, polygon[0]) {
+				continue
+			}
+			if owner < 0 || ringArea(polygon[0]) < ringArea(polygons[owner][0]) {
+				owner = i
+			}
+		}
+		if owner >= 0 {
+			polygons[owner] = append(polygons[owner], hole)
+		}
+	}
+
+	return polygons
+}
+
+// insideRing reports whether a point is inside a ring (even-odd rule)
+func insideRing(p Vertex, ring []Vertex) bool {
+
+	inside := false
+	for i, a := range ring {
+		b := ring[(i+1)%len(ring)]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < a.X+(p.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// geoRing transforms a ring to the coordinates of a GeoJSON polygon: closed (the first position is repeated),
+// counterclockwise if it's an outer boundary and clockwise if it's a hole
+func geoRing(ring []Vertex, t Affine, outer bool) [][2]float64 {
+
+	positions := [][2]float64{}
+	for i := 0; i <= len(ring); i++ {
+		p := t.Apply(ring[i%len(ring)])
+		positions = append(positions, [2]float64{p.X, p.Y})
+	}
+
+	// the signed area is positive for the counterclockwise rings (y grows upward on a map)
+	area := 0.0
+	for i := 0; i+1 < len(positions); i++ {
+		area += positions[i][0]*positions[i+1][1] - positions[i+1][0]*positions[i][1]
+	}
+
+	if (area > 0) != outer {
+		for i, j := 0, len(positions)-1; i < j; i, j = i+1, j-1 {
+			positions[i], positions[j] = positions[j], positions[i]
+		}
+	}
+	return positions
+}
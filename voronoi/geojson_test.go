@@ -0,0 +1,98 @@
+package voronoi
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// geoDocument is a GeoJSON document written by WriteGeoJSON, read back by the tests
+type geoDocument struct {
+	Type     string `json:"type"`
+	Features []struct {
+		ID       int `json:"id"`
+		Geometry *struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+		Properties geoProperties `json:"properties"`
+	} `json:"features"`
+}
+
+// TestGeoJSONRoundTrip exports diagrams as GeoJSON documents on a map, without simplifying the outlines,
+// and checks that the polygons of each feature cover the pixels of its cell (transformed to the map),
+// with the rings oriented as RFC 7946 requires and the properties of the cell
+func TestGeoJSONRoundTrip(t *testing.T) {
+
+	transform := BoundsTransform(90, 70, 9, 40, 18, 47)
+
+	for _, d := range exportedDiagrams(t) {
+
+		var buf bytes.Buffer
+		if err := d.WriteGeoJSON(&buf, GeoJSONOptions{Transform: &transform}); err != nil {
+			t.Fatal(err)
+		}
+		var document geoDocument
+		if err := json.Unmarshal(buf.Bytes(), &document); err != nil {
+			t.Fatal(err)
+		}
+		if document.Type != "FeatureCollection" || len(document.Features) != len(d.Seeds) {
+			t.Fatalf("%s with %d features instead of a FeatureCollection with %d", document.Type, len(document.Features), len(d.Seeds))
+		}
+
+		_, sizes := d.centroids()
+		neighbors := d.Neighbors()
+
+		cells := map[int][][]Vertex{}
+		for _, f := range document.Features {
+			seed := f.Properties.Seed
+			if f.ID != seed || f.Properties.Label != d.Seeds[seed].Label || !reflect.DeepEqual(f.Properties.Neighbors, neighbors[seed]) {
+				t.Fatalf("feature %d has the properties %+v", f.ID, f.Properties)
+			}
+			if math.Abs(f.Properties.Area-float64(sizes[seed])*0.01) > 1e-9 {
+				t.Fatalf("feature %d has area %g instead of %g", f.ID, f.Properties.Area, float64(sizes[seed])*0.01)
+			}
+			if f.Geometry == nil {
+				continue
+			}
+
+			var polygons [][][][2]float64
+			switch f.Geometry.Type {
+			case "Polygon":
+				var polygon [][][2]float64
+				if err := json.Unmarshal(f.Geometry.Coordinates, &polygon); err != nil {
+					t.Fatal(err)
+				}
+				polygons = append(polygons, polygon)
+			case "MultiPolygon":
+				if err := json.Unmarshal(f.Geometry.Coordinates, &polygons); err != nil {
+					t.Fatal(err)
+				}
+			default:
+				t.Fatalf("feature %d has a %s geometry", f.ID, f.Geometry.Type)
+			}
+
+			for _, polygon := range polygons {
+				for i, positions := range polygon {
+					if len(positions) < 4 || positions[0] != positions[len(positions)-1] {
+						t.Fatalf("feature %d has an open ring %v", f.ID, positions)
+					}
+					ring := []Vertex{}
+					for _, p := range positions[:len(positions)-1] {
+						ring = append(ring, Vertex{X: p[0], Y: p[1]})
+					}
+					if outer := i == 0; (ringArea(ring) > 0) != outer {
+						t.Fatalf("feature %d has a ring with the wrong orientation (outer: %v)", f.ID, outer)
+					}
+					cells[seed] = append(cells[seed], ring)
+				}
+			}
+		}
+
+		if diff := paintCells(d, cells, transform); diff != "" {
+			t.Fatal(diff)
+		}
+	}
+}
@@ -0,0 +1,460 @@
+package voronoi
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+/*
+AddSeed adds a seed to the diagram (it gets the next index), without restarting the tessellation:
+its cell grows from the seed as in the tessellation, taking the pixels nearer to it than to their seeds,
+and the other cells keep the rest of their pixels.
+
+If the tessellation is still in progress, the seeds are replaced and the tessellation restarts, as with SetSeeds
+*/
+func (v *Voronoi) AddSeed(seed Point) error {
+
+	s, err := replaceSeeds(v.width, v.height, []Point{seed}, v.config)
+	if err != nil {
+		return err
+	}
+
+	if !v.Done() {
+		return v.SetSeeds(append(append([]Point{}, v.seeds...), seed))
+	}
+
+	v.initBoxes()
+	v.indexes = append(v.indexes, len(v.seeds))
+	v.ids = append(v.ids, len(v.indexes)-1)
+	v.boxes = append(v.boxes, emptyBox())
+	v.seeds = append(v.seeds, s[0])
+	v.growCell(len(v.seeds)-1, 0)
+
+	return nil
+}
+
+/*
+RemoveSeed removes a seed from the diagram (given its index), without restarting the tessellation:
+the pixels of its cell are given to the nearest of the other seeds, and the seeds after it shift down by one index.
+
+If the tessellation is still in progress, the seeds are replaced and the tessellation restarts, as with SetSeeds
+*/
+func (v *Voronoi) RemoveSeed(index int) error {
+
+	if index < 0 || index >= len(v.seeds) {
+		return errors.New("Seed index out of range")
+	}
+
+	if !v.Done() {
+		seeds := append([]Point{}, v.seeds[:index]...)
+		return v.SetSeeds(append(seeds, v.seeds[index+1:]...))
+	}
+
+	v.initBoxes()
+	receivers := v.releaseCell(index)
+
+	// the pixels keep the ids of their seeds, only the indexes of the seeds after the removed one change
+	v.indexes[v.ids[index]] = -1
+	for _, id := range v.ids[index+1:] {
+		v.indexes[id]--
+	}
+	v.seeds = append(v.seeds[:index], v.seeds[index+1:]...)
+	v.ids = append(v.ids[:index], v.ids[index+1:]...)
+	v.boxes = append(v.boxes[:index], v.boxes[index+1:]...)
+
+	shifted := map[int]int{}
+	for i, radius := range receivers {
+		if i > index {
+			i--
+		}
+		shifted[i] = radius
+	}
+	v.regrowCells(shifted)
+
+	return nil
+}
+
+/*
+MoveSeed moves a seed (given its index) to the pixel (x, y), without restarting the tessellation:
+the pixels of its cell are given to the nearest of the other seeds, then the cell grows again from the new position.
+
+If the tessellation is still in progress, the seeds are replaced and the tessellation restarts, as with SetSeeds
+*/
+func (v *Voronoi) MoveSeed(index int, x int, y int) error {
+
+	if index < 0 || index >= len(v.seeds) {
+		return errors.New("Seed index out of range")
+	}
+	if x < 0 || x >= v.width || y < 0 || y >= v.height {
+		return errors.New("Seeds must lay inside the canvas")
+	}
+
+	if !v.Done() {
+		seeds := append([]Point{}, v.seeds...)
+		seeds[index].X = x
+		seeds[index].Y = y
+		return v.SetSeeds(seeds)
+	}
+
+	v.initBoxes()
+	receivers := v.releaseCell(index)
+
+	// the distance of the seed from itself doesn't depend on its position
+	v.seeds[index].X = x
+	v.seeds[index].Y = y
+	v.boxes[index] = emptyBox()
+	v.growCell(index, 0)
+	v.regrowCells(receivers)
+
+	return nil
+}
+
+/*
+The edits give the same diagram of a tessellation of the edited seeds, breaking the ties the same way:
+the tessellation assigns the pixels layer by layer and, within a layer, seed by seed,
+and a seed takes the pixels at the same distance of their current seed,
+so a pixel at the same distance from several seeds goes to the one reaching it in the farthest layer,
+or to the one with the highest index in the same layer (see follows).
+Only a few pixels along the thinnest cells may differ, as whether the tessellation reaches them
+depends on the order it assigns the pixels around them: they may go to another seed at the same distance,
+or even to a farther seed in the tessellation
+*/
+
+// growCell extends the cell of a seed (given its index) layer by layer from the given radius, as the tessellation does,
+// until no further layer may contain any pixel of the cell
+func (v *Voronoi) growCell(index int, from int) {
+
+	// the distances only decrease while the cell grows, so the initial bound stays valid
+	bound := math.Inf(1)
+	if v.config.weighting != Unweighted {
+		bound = v.editBound()
+	}
+
+	for radius := from; ; radius++ {
+
+		grown := false
+		for _, vector := range layer(radius) {
+			grown = v.claimPixel(index, vector.X, vector.Y) || grown
+		}
+
+		if v.config.weighting == Unweighted && !grown && !v.claimedFirst(index, radius) {
+			return
+		}
+		if v.config.weighting != Unweighted && !v.canExtend(index, radius, bound) {
+			return
+		}
+	}
+}
+
+// claimPixel assigns to a seed (given its index) the pixel at the relative coordinates, if the seed takes it from its current seed.
+// It reports whether the seed is at least as near as the current seed, as the tessellation does to decide whether a seed goes on growing
+func (v *Voronoi) claimPixel(index int, dx int, dy int) bool {
+
+	x, y, inside := v.pixelAt(index, dx, dy)
+	if !inside {
+		return false
+	}
+
+	distance := v.distance(index, dx, dy)
+	if p := v.diagram[x][y]; p != nil && p.Distance != nil {
+		owner := v.indexes[v.labels[y*v.width+x]]
+		if distance > *p.Distance {
+			return false
+		}
+		if distance == *p.Distance && !v.follows(index, owner, x, y) {
+			return true
+		}
+	}
+
+	v.assignPointToSeed(index, distance, dx, dy)
+	v.boxes[index].add(dx, dy)
+	return true
+}
+
+/*
+claimedFirst reports whether the tessellation would assign to a seed (given its index) any pixel of the layer at the given radius,
+even if the pixel is finally taken by a nearer seed: this happens if the nearer seed reaches it later, as the tessellation
+lets a seed go on growing after assigning the pixels that are taken later. The seeds stop at the first layer adding nothing,
+so the following layers are reached only this way
+*/
+func (v *Voronoi) claimedFirst(index int, radius int) bool {
+
+	for _, vector := range layer(radius) {
+		x, y, inside := v.pixelAt(index, vector.X, vector.Y)
+		if !inside || v.labels[y*v.width+x] < 0 || !v.follows(v.indexes[v.labels[y*v.width+x]], index, x, y) {
+			continue
+		}
+
+		// the pixel is assigned to the seed unless any seed reaching it before is nearer
+		distance := v.distance(index, vector.X, vector.Y)
+		first := true
+		for i, s := range v.seeds {
+			dx, dy := v.config.wrap(x-s.X, v.width), v.config.wrap(y-s.Y, v.height)
+			if i != index && v.follows(index, i, x, y) && v.distance(i, dx, dy) < distance {
+				first = false
+				break
+			}
+		}
+		if first {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+regrowCells grows again the cells of the seeds that got the pixels of a released cell, without weights,
+given the index of each seed and the nearest layer containing any of its new pixels (the inner layers don't change).
+A seed stops growing at the first layer adding nothing, so it may reach farther when a neighboring cell is released,
+taking the pixels at the same distance of their seeds, or even nearer ones (the tessellation doesn't reach all the pixels of the thinnest cells).
+The weighted seeds grow until no pixel can be taken anymore, so their cells never need to grow again
+*/
+func (v *Voronoi) regrowCells(receivers map[int]int) {
+
+	if v.config.weighting != Unweighted {
+		return
+	}
+
+	indexes := []int{}
+	for index := range receivers {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	for _, index := range indexes {
+		v.growCell(index, receivers[index])
+	}
+}
+
+/*
+releaseCell gives each pixel of the cell of a seed (given its index) to the nearest of the other seeds,
+and returns the seeds that got any of them: the index of each one, with the nearest layer containing its new pixels.
+
+The neighboring cells, and the seeds laying inside the cell (whose cells are empty, as they share the position of the seed),
+give an upper bound of the distances of the pixels from their new seeds: the other seeds are candidates only if they are
+not farther than it from the bounding box of the cell (weighted cells may be disconnected or surround other cells)
+*/
+func (v *Voronoi) releaseCell(index int) map[int]int {
+
+	id := v.ids[index]
+	box := v.boxes[index]
+	pixels := box.pixels(v, index)
+	if len(pixels) == 0 {
+		return nil
+	}
+
+	neighbors := []int{}
+	found := map[int]bool{index: true}
+	add := func(label int) {
+		if label < 0 {
+			return
+		}
+		if i := v.indexes[label]; !found[i] {
+			found[i] = true
+			neighbors = append(neighbors, i)
+		}
+	}
+
+	for _, pos := range pixels {
+		x, y := pos%v.width, pos/v.width
+		for _, n := range [][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+			if v.config.toroidal {
+				n[0], n[1] = mod(n[0], v.width), mod(n[1], v.height)
+			} else if n[0] < 0 || n[0] >= v.width || n[1] < 0 || n[1] >= v.height {
+				continue
+			}
+			add(v.labels[n[1]*v.width+n[0]])
+		}
+	}
+	for i, s := range v.seeds {
+		if v.labels[s.Y*v.width+s.X] == id {
+			add(v.ids[i])
+		}
+	}
+
+	bound := math.Inf(1)
+	if len(neighbors) > 0 {
+		bound = math.Inf(-1)
+		for _, pos := range pixels {
+			_, distance := v.nearestSeed(neighbors, pos)
+			bound = math.Max(bound, distance)
+		}
+	}
+
+	candidates := neighbors
+	for i := range v.seeds {
+		if found[i] {
+			continue
+		}
+		if dx, dy := box.gap(v, index, i); v.distance(i, dx, dy) <= bound {
+			candidates = append(candidates, i)
+		}
+	}
+
+	receivers := map[int]int{}
+	for _, pos := range pixels {
+		x, y := pos%v.width, pos/v.width
+		nearest, distance := v.nearestSeed(candidates, pos)
+
+		// with no other seeds, the pixel is left unassigned
+		if nearest < 0 {
+			v.diagram[x][y] = nil
+			v.labels[pos] = -1
+			v.unassigned++
+			continue
+		}
+
+		d := distance
+		v.diagram[x][y] = &Point{X: x, Y: y, Color: v.seeds[nearest].Color, Distance: &d}
+		v.labels[pos] = v.ids[nearest]
+		v.boxes[nearest].add(v.config.wrap(x-v.seeds[nearest].X, v.width), v.config.wrap(y-v.seeds[nearest].Y, v.height))
+		if radius, found := receivers[nearest]; !found || v.layerOf(nearest, x, y) < radius {
+			receivers[nearest] = v.layerOf(nearest, x, y)
+		}
+
+		// the released pixels get farther from their seeds
+		v.bound = math.Max(v.bound, d)
+	}
+
+	return receivers
+}
+
+// nearestSeed returns the nearest of the candidate seeds (given their indexes) to a pixel (given its position), and its distance.
+// On ties, it's the one the tessellation would assign the pixel to
+func (v *Voronoi) nearestSeed(candidates []int, pos int) (int, float64) {
+
+	x, y := pos%v.width, pos/v.width
+
+	nearest := -1
+	distance := math.Inf(1)
+	for _, c := range candidates {
+		d := v.distance(c, v.config.wrap(x-v.seeds[c].X, v.width), v.config.wrap(y-v.seeds[c].Y, v.height))
+		if d < distance || d == distance && v.follows(c, nearest, x, y) {
+			nearest = c
+			distance = d
+		}
+	}
+	return nearest, distance
+}
+
+// follows reports whether the tessellation assigns the pixel (x, y) to the seed a after the seed b (given their indexes):
+// the seed reaching it in the farther layer comes later, or the one with the highest index in the same layer
+func (v *Voronoi) follows(a int, b int, x int, y int) bool {
+
+	layerA := v.layerOf(a, x, y)
+	layerB := v.layerOf(b, x, y)
+	return layerA > layerB || layerA == layerB && a > b
+}
+
+// layerOf returns the radius of the layer of a seed (given its index) containing the pixel (x, y)
+func (v *Voronoi) layerOf(index int, x int, y int) int {
+	s := v.seeds[index]
+	return abs(v.config.wrap(x-s.X, v.width)) + abs(v.config.wrap(y-s.Y, v.height))
+}
+
+// editBound returns an upper bound of the distances of the pixels from their seeds, for the edits of a weighted diagram
+func (v *Voronoi) editBound() float64 {
+
+	// until every pixel is assigned, any seed may still reach a free pixel
+	if v.unassigned > 0 {
+		return math.Inf(1)
+	}
+	if math.IsInf(v.bound, 1) {
+		v.bound = v.maxDistance()
+	}
+	return v.bound
+}
+
+// initBoxes computes the bounding boxes of the cells, the first time the diagram is edited after the tessellation.
+// The edits keep them up to date: the boxes may get larger than the cells, as they don't shrink with them
+func (v *Voronoi) initBoxes() {
+
+	if v.boxes != nil {
+		return
+	}
+
+	v.boxes = make([]cellBox, len(v.seeds))
+	for i := range v.boxes {
+		v.boxes[i] = emptyBox()
+	}
+
+	for pos, id := range v.labels {
+		if id < 0 {
+			continue
+		}
+		index := v.indexes[id]
+		s := v.seeds[index]
+		v.boxes[index].add(v.config.wrap(pos%v.width-s.X, v.width), v.config.wrap(pos/v.width-s.Y, v.height))
+	}
+}
+
+// cellBox is the bounding box of a cell, as offsets from its seed (across the borders, on a toroidal canvas)
+type cellBox struct {
+	minX, minY int
+	maxX, maxY int
+}
+
+// emptyBox returns the bounding box of an empty cell
+func emptyBox() cellBox {
+	return cellBox{minX: math.MaxInt, minY: math.MaxInt, maxX: math.MinInt, maxY: math.MinInt}
+}
+
+// add extends the box to the given offsets
+func (b *cellBox) add(dx int, dy int) {
+	b.minX = minInt(b.minX, dx)
+	b.minY = minInt(b.minY, dy)
+	b.maxX = maxInt(b.maxX, dx)
+	b.maxY = maxInt(b.maxY, dy)
+}
+
+// gap returns the offsets along the axes between a seed (given its index) and the nearest pixel of the box of the cell of another seed (the owner)
+func (b cellBox) gap(v *Voronoi, owner int, index int) (int, int) {
+
+	o := v.seeds[owner]
+	s := v.seeds[index]
+	dx := axisGap(v.config.wrap(s.X-o.X, v.width), b.minX, b.maxX, v.width, v.config.toroidal)
+	dy := axisGap(v.config.wrap(s.Y-o.Y, v.height), b.minY, b.maxY, v.height, v.config.toroidal)
+	return dx, dy
+}
+
+// axisGap returns the distance along an axis of the given size between an offset and the range min..max (going across the borders, on a toroidal canvas)
+func axisGap(offset int, min int, max int, size int, toroidal bool) int {
+
+	if !toroidal {
+		return maxInt(0, maxInt(min-offset, offset-max))
+	}
+	if mod(offset-min, size) <= max-min {
+		return 0
+	}
+	return minInt(abs(wrapOffset(min-offset, size)), abs(wrapOffset(offset-max, size)))
+}
+
+// pixels returns the positions (row by row) of the pixels in the box owned by a seed (given its index)
+func (b cellBox) pixels(v *Voronoi, index int) []int {
+
+	if b.minX > b.maxX {
+		return nil
+	}
+
+	s := v.seeds[index]
+	id := v.ids[index]
+
+	// on a toroidal canvas the box may be as large as the canvas plus one pixel (the offsets of half the canvas, in both directions)
+	maxX := minInt(b.maxX, b.minX+v.width-1)
+	maxY := minInt(b.maxY, b.minY+v.height-1)
+
+	pixels := []int{}
+	for dy := b.minY; dy <= maxY; dy++ {
+		for dx := b.minX; dx <= maxX; dx++ {
+			x, y := s.X+dx, s.Y+dy
+			if v.config.toroidal {
+				x, y = mod(x, v.width), mod(y, v.height)
+			}
+			if pos := y*v.width + x; v.labels[pos] == id {
+				pixels = append(pixels, pos)
+			}
+		}
+	}
+	return pixels
+}
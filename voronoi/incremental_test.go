@@ -0,0 +1,122 @@
+package voronoi
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// TestSeedEdits checks that a sequence of random edits gives the same diagram of a tessellation of the edited seeds.
+// The wavefront doesn't reach a few pixels of the thinnest cells, and the order it reaches a pixel at the same distance
+// from several seeds depends on the whole tessellation: the labels may only differ on these pixels
+func TestSeedEdits(t *testing.T) {
+
+	cases := []struct {
+		name string
+		opts []Option
+	}{
+		{"euclidean", nil},
+		{"manhattan", []Option{WithMetric(Manhattan{})}},
+		{"chebyshev", []Option{WithMetric(Chebyshev{})}},
+		{"toroidal", []Option{WithToroidal()}},
+		{"toroidal manhattan", []Option{WithToroidal(), WithMetric(Manhattan{})}},
+		{"multiplicative", []Option{WithWeighting(Multiplicative)}},
+		{"power", []Option{WithWeighting(Power)}},
+		{"toroidal power", []Option{WithToroidal(), WithWeighting(Power)}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+
+			r := rand.New(rand.NewSource(7))
+			opts := append([]Option{WithRandSeed(3)}, c.opts...)
+			edited, err := NewVoronoi(64, 48, 12, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			edited.Init()
+			edited.Tessellate(true)
+
+			for step := 0; step < 40; step++ {
+				var edit string
+				seeds := edited.Diagram().Seeds
+
+				switch op := r.Intn(3); {
+				case op == 0 || len(seeds) < 2:
+					seed := Point{X: r.Intn(64), Y: r.Intn(48), Color: &Color{R: uint8(step), A: 255}, Weight: 0.5 + r.Float64()*2, Radius: r.Float64() * 6}
+					edit = fmt.Sprintf("add (%d, %d)", seed.X, seed.Y)
+					err = edited.AddSeed(seed)
+				case op == 1:
+					index := r.Intn(len(seeds))
+					edit = fmt.Sprintf("remove %d", index)
+					err = edited.RemoveSeed(index)
+				default:
+					index, x, y := r.Intn(len(seeds)), r.Intn(64), r.Intn(48)
+					edit = fmt.Sprintf("move %d to (%d, %d)", index, x, y)
+					err = edited.MoveSeed(index, x, y)
+				}
+				if err != nil {
+					t.Fatalf("step %d, %s: %v", step, edit, err)
+				}
+
+				fresh, err := NewVoronoi(64, 48, 0, c.opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := fresh.SetSeeds(edited.Diagram().Seeds); err != nil {
+					t.Fatal(err)
+				}
+				fresh.Tessellate(true)
+
+				if diff := compareDiagrams(edited, fresh); diff != "" {
+					t.Fatalf("step %d, %s: %s", step, edit, diff)
+				}
+			}
+		})
+	}
+}
+
+// compareDiagrams returns a description of the differences between the diagram of an engine and the diagram of a tessellation
+// of the same seeds, except for the pixels at the same distance from several seeds and the ones the tessellation misses
+// (an empty string if there are no other differences)
+func compareDiagrams(edited *Voronoi, fresh *Voronoi) string {
+
+	a := edited.Diagram()
+	b := fresh.Diagram()
+
+	different := 0
+	first := ""
+	for pos := range a.Labels {
+		x, y := pos%a.Width, pos/a.Width
+		tie := a.Distances[pos] == b.Distances[pos] && a.Labels[pos] >= 0 && b.Labels[pos] >= 0
+		if a.Labels[pos] == b.Labels[pos] || tie || b.Labels[pos] != exactLabel(fresh, x, y) {
+			continue
+		}
+		if different == 0 {
+			first = fmt.Sprintf("(%d, %d) has label %d and distance %g instead of %d and %g",
+				x, y, a.Labels[pos], a.Distances[pos], b.Labels[pos], b.Distances[pos])
+		}
+		different++
+	}
+	if different == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d different pixels, the first one at %s", different, first)
+}
+
+// exactLabel returns the index of the seed the pixel (x, y) belongs to, comparing its distances from all the seeds:
+// on ties, the pixel goes to the seed reaching it in the farthest layer, or to the one with the highest index (as in the tessellation)
+func exactLabel(v *Voronoi, x int, y int) int {
+
+	label := -1
+	distance := math.Inf(1)
+	for i, s := range v.seeds {
+		d := v.distance(i, v.config.wrap(x-s.X, v.width), v.config.wrap(y-s.Y, v.height))
+		if d < distance || d == distance && v.follows(i, label, x, y) {
+			label = i
+			distance = d
+		}
+	}
+	return label
+}
@@ -0,0 +1,216 @@
+package voronoi
+
+import (
+	"errors"
+)
+
+// JFA is the engine computing the voronoi diagram with the Jump Flooding Algorithm.
+//
+// Each pass propagates the nearest seed known by each pixel to the pixels at a given step distance,
+// halving the step at each pass (from half the canvas size down to 1 pixel).
+// All the pixels of a pass are independent of each other: the passes are split in row bands
+// computed in parallel, so the algorithm scales with the cores on large canvases.
+// The result is approximated, but the optional correction passes (JFA+1, JFA+2) fix most of the errors
+type JFA struct {
+
+	// diagram size (in pixels)
+	width  int
+	height int
+
+	// seed configuration of the diagram
+	numSeeds int     // number of seeds for the diagram
+	seeds    []Point // list of seeds for the diagram
+
+	steps []int // step sizes of the passes still to be done
+
+	labels []int // index of the nearest seed known by each pixel, row by row (-1 if none)
+	buffer []int // labels computed by the current pass
+
+	config // optional settings (including the random generation of the seeds, see seeding)
+}
+
+// NewJFA creates a new diagram struct computed with the Jump Flooding Algorithm.
+// If explicit seeds are provided through the options, numSeeds is ignored
+func NewJFA(
+	width int,
+	height int,
+	numSeeds int,
+	opts ...Option,
+) (*JFA, error) {
+
+	c, numSeeds, err := setup(width, height, numSeeds, opts)
+	if err != nil {
+		return nil, err
+	}
+	if c.jfaCorrection < 0 || c.jfaCorrection > 2 {
+		return nil, errors.New("The JFA correction passes must be between 0 and 2")
+	}
+
+	return &JFA{
+		width:    width,
+		height:   height,
+		numSeeds: numSeeds,
+		seeds:    []Point{},
+		steps:    []int{},
+		labels:   make([]int, width*height),
+		buffer:   make([]int, width*height),
+		config:   c,
+	}, nil
+}
+
+// Init initializes the diagram and generates a new set of seeds
+func (j *JFA) Init() {
+	j.restart(generateSeeds(j.width, j.height, j.numSeeds, j.config))
+}
+
+// SetSeeds replaces the seeds of the diagram, and restarts the tessellation
+func (j *JFA) SetSeeds(seeds []Point) error {
+
+	s, err := replaceSeeds(j.width, j.height, seeds, j.config)
+	if err != nil {
+		return err
+	}
+	j.restart(s)
+
+	return nil
+}
+
+// restart starts the tessellation from scratch with the given seeds
+func (j *JFA) restart(seeds []Point) {
+
+	j.seeds = seeds
+
+	for pos := range j.labels {
+		j.labels[pos] = -1
+	}
+	for i, s := range j.seeds {
+		pos := s.Y*j.width + s.X
+
+		// with duplicated seeds, the pixel belongs to the nearest one (e.g. the larger one in a power diagram),
+		// and to the first one on ties, as in the passes
+		if j.labels[pos] < 0 || j.distance(s.X, s.Y, i) < j.distance(s.X, s.Y, j.labels[pos]) {
+			j.labels[pos] = i
+		}
+	}
+
+	// the steps go from half the size of the canvas (rounded to a power of 2) down to 1
+	size := 1
+	for size < j.width || size < j.height {
+		size *= 2
+	}
+	j.steps = []int{}
+	for step := size / 2; step >= 1; step /= 2 {
+		j.steps = append(j.steps, step)
+	}
+
+	// the correction passes (JFA+1 and JFA+2) repeat the smallest steps
+	switch j.config.jfaCorrection {
+	case 1:
+		j.steps = append(j.steps, 1)
+	case 2:
+		j.steps = append(j.steps, 2, 1)
+	}
+}
+
+// Tessellate computes the voronoi diagram.
+// If hideIterations is false, a single pass is computed at each call
+func (j *JFA) Tessellate(hideIterations bool) error {
+
+	for len(j.steps) > 0 {
+		j.pass(j.steps[0])
+		j.steps = j.steps[1:]
+
+		if !hideIterations {
+			// this breaks the computation to the current state of the tessellation,
+			// useful to show the evolution of the diagram
+			break
+		}
+	}
+
+	return nil
+}
+
+// Done reports whether the tessellation is complete, i.e. all the passes are done
+func (j *JFA) Done() bool {
+	return len(j.steps) == 0
+}
+
+// pass propagates the labels to the pixels at the given step distance,
+// splitting the canvas in bands of rows computed in parallel
+func (j *JFA) pass(step int) {
+
+	parallelRows(j.height, j.config.numWorkers(), func(band int, from int, to int) {
+		for y := from; y < to; y++ {
+			for x := 0; x < j.width; x++ {
+				j.buffer[y*j.width+x] = j.nearest(x, y, step)
+			}
+		}
+	})
+
+	j.labels, j.buffer = j.buffer, j.labels
+}
+
+// nearest returns the nearest seed of the pixel (x, y) among the ones known by the pixel itself
+// and by its 8 neighbours at the step distance (wrapping around the borders, on a toroidal canvas).
+// In case of a tie the seed coming first wins, so the result doesn't depend on the order of the computation
+func (j *JFA) nearest(x int, y int, step int) int {
+
+	best := j.labels[y*j.width+x]
+	bestDistance := j.distance(x, y, best)
+
+	for dy := -step; dy <= step; dy += step {
+		for dx := -step; dx <= step; dx += step {
+			nx := x + dx
+			ny := y + dy
+			if j.config.toroidal {
+				nx = mod(nx, j.width)
+				ny = mod(ny, j.height)
+			}
+			if nx < 0 || nx >= j.width || ny < 0 || ny >= j.height {
+				continue
+			}
+
+			candidate := j.labels[ny*j.width+nx]
+			if candidate < 0 || candidate == best {
+				continue
+			}
+
+			distance := j.distance(x, y, candidate)
+			if best < 0 || distance < bestDistance || (distance == bestDistance && candidate < best) {
+				best = candidate
+				bestDistance = distance
+			}
+		}
+	}
+
+	return best
+}
+
+// distance computes the distance between the pixel (x, y) and a seed (given its index)
+func (j *JFA) distance(x int, y int, seedIndex int) float64 {
+	if seedIndex < 0 {
+		return 0
+	}
+	seed := j.seeds[seedIndex]
+	return j.config.distance(seed, j.config.wrap(x-seed.X, j.width), j.config.wrap(y-seed.Y, j.height))
+}
+
+// Diagram returns a snapshot of the current state of the tessellation
+func (j *JFA) Diagram() *Diagram {
+
+	d := newDiagram(j.width, j.height, j.seeds, j.config)
+	copy(d.Labels, j.labels)
+
+	for pos, l := range d.Labels {
+		d.Distances[pos] = j.distance(pos%j.width, pos/j.width, l)
+	}
+
+	return d
+}
+
+// ToPixels generates the byte array containing the information to render the diagram.
+// Each row of the canvas is concatenated to obtain a one-dimensional array.
+// Each pixel is represented by 4 bytes, representing the Red, Green, Blue and Alpha info.
+func (j *JFA) ToPixels() []byte {
+	return j.Diagram().ToPixels()
+}
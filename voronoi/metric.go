@@ -0,0 +1,100 @@
+package voronoi
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Metric measures the distance between a pixel and a seed
+type Metric interface {
+
+	// Distance returns the distance corresponding to the offset (dx, dy) between a pixel and a seed
+	Distance(dx int, dy int) float64
+
+	// String returns the name of the metric
+	String() string
+}
+
+// Euclidean is the usual straight-line distance (L2), producing convex polygonal cells
+type Euclidean struct{}
+
+// Distance returns the euclidean distance of the offset (dx, dy)
+func (Euclidean) Distance(dx int, dy int) float64 {
+	return math.Sqrt(float64(dx*dx + dy*dy))
+}
+
+func (Euclidean) String() string {
+	return "euclidean"
+}
+
+// Manhattan is the taxicab distance (L1), measured along the axes as in a grid of city blocks
+type Manhattan struct{}
+
+// Distance returns the manhattan distance of the offset (dx, dy)
+func (Manhattan) Distance(dx int, dy int) float64 {
+	return float64(abs(dx) + abs(dy))
+}
+
+func (Manhattan) String() string {
+	return "manhattan"
+}
+
+// Chebyshev is the chessboard distance (L∞), the larger of the offsets along the axes
+type Chebyshev struct{}
+
+// Distance returns the chebyshev distance of the offset (dx, dy)
+func (Chebyshev) Distance(dx int, dy int) float64 {
+	return float64(maxInt(abs(dx), abs(dy)))
+}
+
+func (Chebyshev) String() string {
+	return "chebyshev"
+}
+
+// Minkowski is the generalization (Lp) of the other metrics:
+// P=1 is the manhattan distance, P=2 the euclidean one, and it approaches the chebyshev distance as P grows
+type Minkowski struct {
+	P float64 // order of the metric (at least 1)
+}
+
+// Distance returns the minkowski distance of order P of the offset (dx, dy)
+func (m Minkowski) Distance(dx int, dy int) float64 {
+	return math.Pow(math.Pow(float64(abs(dx)), m.P)+math.Pow(float64(abs(dy)), m.P), 1/m.P)
+}
+
+func (m Minkowski) String() string {
+	return fmt.Sprintf("minkowski(p=%g)", m.P)
+}
+
+// ParseMetric returns the metric with the given name (euclidean, manhattan, chebyshev or minkowski).
+// The order p is used only by the minkowski metric
+func ParseMetric(name string, p float64) (Metric, error) {
+
+	switch strings.ToLower(name) {
+	case "euclidean", "l2":
+		return Euclidean{}, nil
+	case "manhattan", "l1":
+		return Manhattan{}, nil
+	case "chebyshev", "linf":
+		return Chebyshev{}, nil
+	case "minkowski", "lp":
+		m := Minkowski{P: p}
+		return m, validateMetric(m)
+	default:
+		return nil, fmt.Errorf("Unknown metric %q", name)
+	}
+}
+
+// validateMetric checks that the metric is usable
+func validateMetric(m Metric) error {
+
+	if m == nil {
+		return errors.New("The metric cannot be nil")
+	}
+	if mk, ok := m.(Minkowski); ok && (math.IsNaN(mk.P) || mk.P < 1 || math.IsInf(mk.P, 0)) {
+		return errors.New("The order of the minkowski metric must be a finite number, at least 1")
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package voronoi
+
+// Color is the RGBA color of a cell
+type Color struct {
+	R byte `json:"r"`
+	G byte `json:"g"`
+	B byte `json:"b"`
+	A byte `json:"a"`
+}
+
+// Point is a pixel of the diagram.
+// It is also used to represent the seeds of the diagram and the coordinates relative to a seed
+type Point struct {
+	X        int
+	Y        int
+	Distance *float64 // distance from the seed of the cell the point belongs to (nil if not assigned yet)
+	Color    *Color   // color of the cell the point belongs to (nil if not assigned yet)
+
+	Weight float64 // weight of the seed, used by the weighted diagrams (0 is the same as 1)
+	Radius float64 // radius of the seed, used by the power diagrams
+	Label  string  // optional name of the seed (e.g. read from a seeds file)
+
+	Velocity *Vertex // velocity of the seed in pixels per second, used by the kinetic diagrams (nil if still)
+}
@@ -0,0 +1,153 @@
+package voronoi
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// Border is the way the moving seeds behave at the borders of the canvas
+type Border int
+
+const (
+	// Bounce reflects the seeds reaching a border, reversing their velocity across it
+	Bounce Border = iota
+
+	// Wrap brings the seeds leaving the canvas back from the opposite border, as on a toroidal canvas
+	Wrap
+)
+
+func (b Border) String() string {
+	switch b {
+	case Bounce:
+		return "bounce"
+	case Wrap:
+		return "wrap"
+	default:
+		return fmt.Sprintf("Border(%d)", int(b))
+	}
+}
+
+// ParseBorder returns the border with the given name (bounce or wrap)
+func ParseBorder(name string) (Border, error) {
+	switch strings.ToLower(name) {
+	case "bounce", "":
+		return Bounce, nil
+	case "wrap":
+		return Wrap, nil
+	default:
+		return Bounce, fmt.Errorf("Unknown border %q", name)
+	}
+}
+
+// RandomVelocity returns a velocity with the given speed (in pixels per second) and a random direction
+func RandomVelocity(speed float64, r *rand.Rand) *Vertex {
+	angle := 2 * math.Pi * r.Float64()
+	return &Vertex{X: speed * math.Cos(angle), Y: speed * math.Sin(angle)}
+}
+
+/*
+Motion moves the seeds of a diagram along their velocities, to animate it (kinetic diagram).
+
+The seeds lay on the pixels, so the motion keeps their exact positions between the steps:
+this way the slow seeds move too, a fraction of pixel at a time
+*/
+type Motion struct {
+	width  int
+	height int
+	border Border
+
+	positions []Vertex // exact position of each seed
+}
+
+// NewMotion creates the motion of the seeds of a width*height diagram
+func NewMotion(width int, height int, border Border) *Motion {
+	return &Motion{
+		width:     width,
+		height:    height,
+		border:    border,
+		positions: []Vertex{},
+	}
+}
+
+/*
+Step moves the seeds along their velocities for dt seconds, and returns them in their new positions (ready for SetSeeds).
+The seeds without a velocity stay still, and the bouncing seeds get their reflected velocity.
+
+The seeds changed since the previous step (e.g. added, removed or dragged elsewhere) restart from their pixel
+*/
+func (m *Motion) Step(seeds []Point, dt float64) []Point {
+
+	moved := append([]Point{}, seeds...)
+	if len(m.positions) > len(moved) {
+		m.positions = m.positions[:len(moved)]
+	}
+
+	for i := range moved {
+		s := &moved[i]
+
+		if i == len(m.positions) {
+			m.positions = append(m.positions, Vertex{X: float64(s.X), Y: float64(s.Y)})
+		}
+		p := &m.positions[i]
+		if x, y := m.pixel(*p); x != s.X || y != s.Y {
+			*p = Vertex{X: float64(s.X), Y: float64(s.Y)}
+		}
+
+		if s.Velocity == nil {
+			continue
+		}
+
+		velocity := *s.Velocity
+		p.X, velocity.X = m.moveAxis(p.X, velocity.X, dt, m.width)
+		p.Y, velocity.Y = m.moveAxis(p.Y, velocity.Y, dt, m.height)
+
+		s.X, s.Y = m.pixel(*p)
+		if velocity != *s.Velocity {
+			s.Velocity = &velocity
+		}
+	}
+
+	return moved
+}
+
+// moveAxis moves a position along an axis of the given size, and returns the new position and velocity
+func (m *Motion) moveAxis(position float64, velocity float64, dt float64, size int) (float64, float64) {
+
+	position += velocity * dt
+
+	if m.border == Wrap {
+		position = math.Mod(position, float64(size))
+		if position < 0 {
+			position += float64(size)
+		}
+		return position, velocity
+	}
+
+	// the seeds bounce on the centers of the pixels along the borders
+	limit := float64(size - 1)
+	if limit == 0 {
+		return 0, velocity
+	}
+	// each border crossed reflects the seed (a fast seed may cross several of them in a step)
+	crossings := math.Floor(position / limit)
+	position -= crossings * limit
+	if math.Mod(crossings, 2) != 0 {
+		position = limit - position
+		velocity = -velocity
+	}
+	return position, velocity
+}
+
+// pixel returns the pixel nearest to an exact position
+func (m *Motion) pixel(p Vertex) (int, int) {
+
+	x := int(math.Round(p.X))
+	y := int(math.Round(p.Y))
+	if m.border == Wrap {
+		x = mod(x, m.width)
+		y = mod(y, m.height)
+	}
+	return x, y
+}
@@ -0,0 +1,62 @@
+package voronoi
+
+import (
+	"testing"
+)
+
+// TestMotionStep checks the moves of the seeds on a 10x8 canvas: bouncing on the centers of the border pixels
+// (also across several borders in a step) or wrapping around, and restarting from their pixel when dragged elsewhere
+func TestMotionStep(t *testing.T) {
+
+	cases := []struct {
+		border   Border
+		x, y     int
+		velocity Vertex
+		dt       float64
+		steps    int
+		position [2]int // expected pixel
+		reversed [2]bool
+	}{
+		{Bounce, 8, 5, Vertex{X: 3, Y: 0}, 1, 1, [2]int{7, 5}, [2]bool{true, false}},
+		{Bounce, 1, 1, Vertex{X: -2, Y: -4}, 1, 1, [2]int{1, 3}, [2]bool{true, true}},
+		{Bounce, 0, 0, Vertex{X: 20, Y: 0}, 1, 1, [2]int{2, 0}, [2]bool{false, false}},
+		{Bounce, 2, 2, Vertex{X: 0, Y: 1}, 0.25, 8, [2]int{2, 4}, [2]bool{false, false}},
+		{Wrap, 8, 5, Vertex{X: 3, Y: -7}, 1, 1, [2]int{1, 6}, [2]bool{false, false}},
+		{Wrap, 9, 7, Vertex{X: 0.4, Y: 0.4}, 1, 3, [2]int{0, 0}, [2]bool{false, false}},
+		{Wrap, 0, 0, Vertex{X: -25, Y: 0}, 1, 1, [2]int{5, 0}, [2]bool{false, false}},
+	}
+
+	for _, c := range cases {
+		m := NewMotion(10, 8, c.border)
+		velocity := c.velocity
+		seeds := []Point{{X: c.x, Y: c.y, Velocity: &velocity}, {X: 4, Y: 4}}
+
+		for i := 0; i < c.steps; i++ {
+			seeds = m.Step(seeds, c.dt)
+		}
+
+		s := seeds[0]
+		if [2]int{s.X, s.Y} != c.position {
+			t.Fatalf("%v from (%d, %d) at %v: (%d, %d) instead of %v", c.border, c.x, c.y, c.velocity, s.X, s.Y, c.position)
+		}
+		reversed := [2]bool{s.Velocity.X == -c.velocity.X && c.velocity.X != 0, s.Velocity.Y == -c.velocity.Y && c.velocity.Y != 0}
+		if reversed != c.reversed {
+			t.Fatalf("%v from (%d, %d) at %v: velocity %v", c.border, c.x, c.y, c.velocity, *s.Velocity)
+		}
+		if velocity != c.velocity {
+			t.Fatal("the velocity of the seed given to the step changed")
+		}
+		if seeds[1].X != 4 || seeds[1].Y != 4 || seeds[1].Velocity != nil {
+			t.Fatalf("the still seed moved to %+v", seeds[1])
+		}
+	}
+
+	// a seed dragged elsewhere restarts from its new pixel, without the fraction of pixel it moved before
+	m := NewMotion(10, 8, Bounce)
+	seeds := m.Step([]Point{{X: 2, Y: 2, Velocity: &Vertex{X: 0.4}}}, 1)
+	seeds[0].X, seeds[0].Y = 6, 6
+	seeds = m.Step(seeds, 1)
+	if seeds[0].X != 6 || seeds[0].Y != 6 {
+		t.Fatalf("the dragged seed is at (%d, %d) instead of (6, 6)", seeds[0].X, seeds[0].Y)
+	}
+}
@@ -0,0 +1,250 @@
+package voronoi
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Option customizes the engine built by a constructor
+type Option func(*config)
+
+// config holds the optional settings of an engine
+type config struct {
+	seeds []Point // explicit set of seeds, used in place of the random generation
+
+	metric    Metric    // metric used to measure the distances
+	weighting Weighting // way the weights of the seeds affect the distances
+	toroidal  bool      // if true, the canvas wraps around both axes
+
+	seeding // random generation of the seeds
+
+	jfaCorrection int // number of correction passes of the Jump Flooding Algorithm
+
+	workers int // number of goroutines computing the diagram in parallel (0 for the number of CPUs)
+}
+
+/*
+seeding holds the settings of the random generation of the seeds.
+The engines embed it (through their config), sharing its methods to read and change them
+*/
+type seeding struct {
+	randSeed      int64         // seed of the random generation of the seeds
+	fixedRandSeed bool          // if false, the random seed is taken from the current time
+	generator     SeedGenerator // distribution of the random seeds
+}
+
+// RandSeed returns the random seed the seeds are generated from
+func (s *seeding) RandSeed() int64 {
+	return s.randSeed
+}
+
+// SetRandSeed changes the random seed the seeds are generated from, starting from the next Init
+func (s *seeding) SetRandSeed(seed int64) {
+	s.randSeed = seed
+}
+
+// SeedGenerator returns the distribution of the random seeds
+func (s *seeding) SeedGenerator() SeedGenerator {
+	return s.generator
+}
+
+// SetSeedGenerator changes the distribution of the random seeds, starting from the next Init
+func (s *seeding) SetSeedGenerator(g SeedGenerator) {
+	s.generator = g
+}
+
+// WithSeeds makes the engine use the given seeds instead of generating them randomly.
+// Only the coordinates, the color, the weight, the radius and the label of each seed are taken into account;
+// seeds without a color are shown as black cells
+func WithSeeds(seeds []Point) Option {
+	return func(c *config) {
+		c.seeds = append([]Point{}, seeds...)
+	}
+}
+
+// WithMetric makes the engine measure the distances with the given metric (euclidean by default)
+func WithMetric(m Metric) Option {
+	return func(c *config) {
+		c.metric = m
+	}
+}
+
+// WithWeighting makes the engine take into account the weights of the seeds (unweighted by default).
+// Random seeds get a random weight between 0.5 and 2, or a random radius with the power weighting
+func WithWeighting(w Weighting) Option {
+	return func(c *config) {
+		c.weighting = w
+	}
+}
+
+// WithToroidal makes the canvas wrap around both axes (periodic boundaries):
+// the distances are measured across the borders, so the resulting image tiles seamlessly
+func WithToroidal() Option {
+	return func(c *config) {
+		c.toroidal = true
+	}
+}
+
+// WithRandSeed makes the random generation of the seeds reproducible:
+// the same random seed always generates the same seeds, with the same colors (by default, the random seed is the current time)
+func WithRandSeed(seed int64) Option {
+	return func(c *config) {
+		c.randSeed = seed
+		c.fixedRandSeed = true
+	}
+}
+
+// WithSeedGenerator makes the engine place the random seeds with the given distribution (uniform by default).
+// It is ignored when the seeds are explicitly provided
+func WithSeedGenerator(g SeedGenerator) Option {
+	return func(c *config) {
+		c.generator = g
+	}
+}
+
+// WithJFACorrection adds correction passes to the Jump Flooding Algorithm:
+// 1 for JFA+1 (an additional pass with step 1), 2 for JFA+2 (additional passes with steps 2 and 1).
+// It is ignored by the other engines
+func WithJFACorrection(passes int) Option {
+	return func(c *config) {
+		c.jfaCorrection = passes
+	}
+}
+
+// WithWorkers sets the number of goroutines computing the diagram in parallel (by default, the number of CPUs).
+// The result doesn't depend on it. It is used by the wavefront and JFA engines
+func WithWorkers(n int) Option {
+	return func(c *config) {
+		c.workers = n
+	}
+}
+
+// newConfig applies the options to an empty configuration
+func newConfig(opts []Option) config {
+	c := config{
+		metric:  Euclidean{},
+		seeding: seeding{generator: Uniform{}},
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// setup applies the options and validates them against a width*height canvas.
+// It returns the resulting configuration and the actual number of seeds of the diagram
+func setup(width int, height int, numSeeds int, opts []Option) (config, int, error) {
+
+	c := newConfig(opts)
+	if c.seeds != nil {
+		numSeeds = len(c.seeds)
+	}
+	if !c.fixedRandSeed {
+		c.randSeed = time.Now().UnixNano()
+	}
+
+	if numSeeds > width*height {
+		return c, 0, errors.New("Number of seeds cannot be more than the pixels in the canvas")
+	}
+	if err := c.validateSeeds(width, height); err != nil {
+		return c, 0, err
+	}
+	if c.generator == nil {
+		return c, 0, errors.New("The seed generator cannot be nil")
+	}
+	if c.workers < 0 {
+		return c, 0, errors.New("The number of workers cannot be negative")
+	}
+	if err := validateMetric(c.metric); err != nil {
+		return c, 0, err
+	}
+	if err := c.validateWeights(); err != nil {
+		return c, 0, err
+	}
+
+	return c, numSeeds, nil
+}
+
+// validateWeights checks the weighting and the weights of the configured seeds.
+// A weight of 0 is accepted, as it means the seed is not weighted (the same as 1)
+func (c config) validateWeights() error {
+
+	if c.weighting != Unweighted && c.weighting != Multiplicative && c.weighting != Power {
+		return fmt.Errorf("Unknown weighting %v", c.weighting)
+	}
+	if _, ok := c.metric.(Euclidean); c.weighting == Power && !ok {
+		return errors.New("The power weighting supports only the euclidean metric")
+	}
+
+	for _, s := range c.seeds {
+		if s.Weight < 0 || math.IsNaN(s.Weight) || math.IsInf(s.Weight, 0) {
+			return errors.New("Seed weights must be finite and not negative (0 is the same as 1)")
+		}
+		if s.Radius < 0 || math.IsNaN(s.Radius) || math.IsInf(s.Radius, 0) {
+			return errors.New("Seed radii must be finite and not negative")
+		}
+	}
+	return nil
+}
+
+// distance measures the distance between a seed and a pixel, given their offset
+func (c config) distance(seed Point, dx int, dy int) float64 {
+	switch c.weighting {
+	case Multiplicative:
+		return c.metric.Distance(dx, dy) / seed.weight()
+	case Power:
+		return float64(dx*dx+dy*dy) - seed.Radius*seed.Radius
+	default:
+		return c.metric.Distance(dx, dy)
+	}
+}
+
+// wrap brings an offset along an axis of the given size to the shortest one across the borders,
+// if the canvas is toroidal
+func (c config) wrap(d int, size int) int {
+	if !c.toroidal {
+		return d
+	}
+	return wrapOffset(d, size)
+}
+
+// wrapOffset brings an offset along an axis of the given size to the shortest one across the borders
+func wrapOffset(d int, size int) int {
+
+	d %= size
+	if d > size/2 {
+		d -= size
+	} else if d < -size/2 {
+		d += size
+	}
+	return d
+}
+
+// lowerBound is the smallest distance between a seed and the pixels at the given radius or farther,
+// where the radius is measured along the axes (as the layers of the wavefront)
+func (c config) lowerBound(seed Point, radius int) float64 {
+
+	// for every metric, the closest pixels of a layer lay on its diagonals
+	nearest := float64(radius) * c.metric.Distance(1, 1) / 2
+
+	switch c.weighting {
+	case Multiplicative:
+		return nearest / seed.weight()
+	case Power:
+		return nearest*nearest - seed.Radius*seed.Radius
+	default:
+		return nearest
+	}
+}
+
+// validateSeeds checks that every configured seed lays in a width*height canvas
+func (c config) validateSeeds(width int, height int) error {
+	for _, s := range c.seeds {
+		if s.X < 0 || s.X >= width || s.Y < 0 || s.Y >= height {
+			return errors.New("Seeds must lay inside the canvas")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package voronoi
+
+import (
+	"runtime"
+	"sync"
+)
+
+// numWorkers returns the number of goroutines computing the diagram in parallel
+func (c config) numWorkers() int {
+	if c.workers > 0 {
+		return c.workers
+	}
+	return runtime.NumCPU()
+}
+
+// parallelRows splits the rows of a canvas in bands, up to one per worker, and calls f on each band in parallel
+// (with the index of the band and its rows, from included and to excluded). It returns when all the bands are done
+func parallelRows(height int, workers int, f func(band int, from int, to int)) {
+
+	if workers > height {
+		workers = height
+	}
+	if workers <= 1 {
+		f(0, 0, height)
+		return
+	}
+	size := (height + workers - 1) / workers
+
+	wg := sync.WaitGroup{}
+	for band, from := 0, 0; from < height; band, from = band+1, from+size {
+		to := minInt(from+size, height)
+
+		wg.Add(1)
+		go func(band int, from int, to int) {
+			defer wg.Done()
+			f(band, from, to)
+		}(band, from, to)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,43 @@
+package voronoi
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestWorkers checks that the engines computing the diagram in parallel give the same labels and distances
+// with any number of workers, as a sequential computation
+func TestWorkers(t *testing.T) {
+
+	cases := []struct {
+		name string
+		opts []Option
+	}{
+		{"euclidean", nil},
+		{"manhattan", []Option{WithMetric(Manhattan{})}},
+		{"toroidal", []Option{WithToroidal()}},
+		{"multiplicative", []Option{WithWeighting(Multiplicative)}},
+		{"power", []Option{WithWeighting(Power)}},
+		{"jfa+2", []Option{WithJFACorrection(2)}},
+	}
+
+	for _, algorithm := range []Algorithm{AlgorithmWavefront, AlgorithmJFA} {
+		for _, c := range cases {
+			t.Run(fmt.Sprintf("%s %s", algorithm, c.name), func(t *testing.T) {
+
+				opts := append([]Option{WithRandSeed(11)}, c.opts...)
+				sequential := tessellate(t, algorithm, 1, opts)
+
+				for _, workers := range []int{2, 3, 8, 0} {
+					d := tessellate(t, algorithm, workers, opts)
+					for pos := range sequential.Labels {
+						if d.Labels[pos] != sequential.Labels[pos] || d.Distances[pos] != sequential.Distances[pos] {
+							t.Fatalf("%d workers: (%d, %d) has label %d and distance %g instead of %d and %g",
+								workers, pos%d.Width, pos/d.Width, d.Labels[pos], d.Distances[pos], sequential.Labels[pos], sequential.Distances[pos])
+						}
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package voronoi
+
+import (
+	"math"
+)
+
+// CellPolygon is the outline of a cell, traced along the borders of its pixels
+type CellPolygon struct {
+	Seed int // index of the seed of the cell
+
+	// closed rings bounding the cell (the last vertex is not repeated), in the coordinates of the pixel corners:
+	// the outer boundaries are clockwise as seen on the canvas (y grows downward), and the holes counterclockwise.
+	// A cell split in several pieces has several outer boundaries, an empty cell has no rings
+	Rings [][]Vertex
+}
+
+// directions along the borders of the pixels, each one a right turn (as seen on the canvas) from the previous one
+var directions = [4][2]int{
+	{1, 0},  // right
+	{0, 1},  // down
+	{-1, 0}, // left
+	{0, -1}, // up
+}
+
+// chain is a piece of boundary between two cells, running between two junctions of the boundaries
+// (or a closed loop, when a cell is surrounded by another one)
+type chain struct {
+	points []Vertex
+
+	right int // label of the pixels on the right side of the chain (-2 outside the canvas)
+	left  int // label of the pixels on the left side of the chain (-2 outside the canvas)
+
+	start int // junction the chain starts from (-1 for a loop)
+	end   int // junction the chain ends to (-1 for a loop)
+
+	firstDirection int // direction of the first step of the chain
+	lastDirection  int // direction of the last step of the chain
+}
+
+// reversed returns the same chain walked in the opposite direction
+func (c chain) reversed() chain {
+
+	points := make([]Vertex, len(c.points))
+	for i, p := range c.points {
+		points[len(points)-1-i] = p
+	}
+	return chain{
+		points:         points,
+		right:          c.left,
+		left:           c.right,
+		start:          c.end,
+		end:            c.start,
+		firstDirection: (c.lastDirection + 2) % 4,
+		lastDirection:  (c.firstDirection + 2) % 4,
+	}
+}
+
+/*
+Polygons traces the outline of each cell, following the borders between the pixels of different cells
+
+The boundaries are split in chains between the junctions (the points where three or more cells meet, and the corners of the canvas),
+and each chain is simplified with the Douglas-Peucker algorithm: no vertex of the original chain is farther than the tolerance (in pixels)
+from the simplified one. As the neighboring cells share the same simplified chains, they still fit together without gaps.
+A zero tolerance only merges the aligned steps along the pixels; a large one may collapse the smallest cells.
+
+The unassigned pixels don't belong to any polygon, and on a toroidal canvas the cells are cut at the borders
+*/
+func (d *Diagram) Polygons(tolerance float64) []CellPolygon {
+
+	chains := d.traceChains()
+	for i := range chains {
+		chains[i].points = simplifyChain(chains[i].points, chains[i].start < 0, tolerance)
+	}
+
+	// the chains of each cell, walked with the cell on their right side
+	cellChains := make([][]chain, len(d.Seeds))
+	for _, c := range chains {
+		if c.right >= 0 {
+			cellChains[c.right] = append(cellChains[c.right], c)
+		}
+		if c.left >= 0 {
+			cellChains[c.left] = append(cellChains[c.left], c.reversed())
+		}
+	}
+
+	polygons := make([]CellPolygon, len(d.Seeds))
+	for i := range polygons {
+		polygons[i] = CellPolygon{
+			Seed:  i,
+			Rings: linkChains(cellChains[i]),
+		}
+	}
+	return polygons
+}
+
+// traceChains splits the boundaries between the cells in chains
+func (d *Diagram) traceChains() []chain {
+
+	// the vertices are the corners of the pixels, (width+1)*(height+1)
+	stride := d.Width + 1
+	vertex := func(x int, y int) int {
+		return y*stride + x
+	}
+
+	label := func(x int, y int) int {
+		if x < 0 || x >= d.Width || y < 0 || y >= d.Height {
+			return -2
+		}
+		return d.Labels[y*d.Width+x]
+	}
+
+	// labels on the right and on the left of the step from (x, y) in the given direction
+	sides := func(x int, y int, direction int) (int, int) {
+		switch direction {
+		case 0:
+			return label(x, y), label(x, y-1)
+		case 1:
+			return label(x-1, y), label(x, y)
+		case 2:
+			return label(x-1, y-1), label(x-1, y)
+		default:
+			return label(x, y-1), label(x-1, y-1)
+		}
+	}
+
+	// steps along a boundary leaving each vertex, one bit for each direction
+	steps := make([]uint8, stride*(d.Height+1))
+	for y := 0; y <= d.Height; y++ {
+		for x := 0; x <= d.Width; x++ {
+			for direction, delta := range directions {
+				nx, ny := x+delta[0], y+delta[1]
+				if nx < 0 || nx > d.Width || ny < 0 || ny > d.Height {
+					continue
+				}
+				if right, left := sides(x, y, direction); right != left {
+					steps[vertex(x, y)] |= 1 << direction
+				}
+			}
+		}
+	}
+
+	junction := func(x int, y int) bool {
+		degree := 0
+		for direction := range directions {
+			if steps[vertex(x, y)]&(1<<direction) != 0 {
+				degree++
+			}
+		}
+		corner := (x == 0 || x == d.Width) && (y == 0 || y == d.Height)
+		return degree > 2 || corner
+	}
+
+	visited := make([]uint8, len(steps))
+
+	// walk follows a boundary from (x, y) in the given direction, until it reaches a junction or the starting point
+	walk := func(x int, y int, direction int) chain {
+
+		c := chain{
+			points:         []Vertex{{X: float64(x), Y: float64(y)}},
+			start:          -1,
+			end:            -1,
+			firstDirection: direction,
+		}
+		c.right, c.left = sides(x, y, direction)
+		if junction(x, y) {
+			c.start = vertex(x, y)
+		}
+
+		startX, startY := x, y
+		for {
+			visited[vertex(x, y)] |= 1 << direction
+			x += directions[direction][0]
+			y += directions[direction][1]
+			visited[vertex(x, y)] |= 1 << ((direction + 2) % 4)
+			c.points = append(c.points, Vertex{X: float64(x), Y: float64(y)})
+			c.lastDirection = direction
+
+			if junction(x, y) {
+				c.end = vertex(x, y)
+				return c
+			}
+			if x == startX && y == startY {
+				// closed loop: the last point repeats the first one
+				c.points = c.points[:len(c.points)-1]
+				return c
+			}
+
+			// away from the junctions, there is only one way forward
+			for next := range directions {
+				if next != (direction+2)%4 && steps[vertex(x, y)]&(1<<next) != 0 {
+					direction = next
+					break
+				}
+			}
+		}
+	}
+
+	chains := []chain{}
+
+	// first the chains between the junctions, then the closed loops
+	for _, junctions := range []bool{true, false} {
+		for y := 0; y <= d.Height; y++ {
+			for x := 0; x <= d.Width; x++ {
+				if junction(x, y) != junctions {
+					continue
+				}
+				for direction := range directions {
+					pending := steps[vertex(x, y)] &^ visited[vertex(x, y)]
+					if pending&(1<<direction) != 0 {
+						chains = append(chains, walk(x, y, direction))
+					}
+				}
+			}
+		}
+	}
+
+	return chains
+}
+
+// linkChains joins the chains of a cell (with the cell on their right side) in closed rings
+func linkChains(chains []chain) [][]Vertex {
+
+	rings := [][]Vertex{}
+	used := make([]bool, len(chains))
+
+	for first, c := range chains {
+		if used[first] {
+			continue
+		}
+		used[first] = true
+
+		if c.start < 0 {
+			rings = appendRing(rings, c.points)
+			continue
+		}
+
+		ring := append([]Vertex{}, c.points[:len(c.points)-1]...)
+		current := c
+		for {
+			// where more chains of the cell leave the same junction (cells touching at a corner),
+			// the sharpest right turn keeps the pieces of the cell apart
+			next := -1
+			bestTurn := 4
+			for i, candidate := range chains {
+				if candidate.start != current.end || (used[i] && i != first) {
+					continue
+				}
+				turn := (current.lastDirection - candidate.firstDirection + 5) % 4
+				if turn < bestTurn {
+					next = i
+					bestTurn = turn
+				}
+			}
+
+			if next < 0 || next == first {
+				break
+			}
+			used[next] = true
+			current = chains[next]
+			ring = append(ring, current.points[:len(current.points)-1]...)
+		}
+		rings = appendRing(rings, ring)
+	}
+
+	return rings
+}
+
+// appendRing adds a ring to the list, unless the simplification collapsed it
+func appendRing(rings [][]Vertex, ring []Vertex) [][]Vertex {
+	if len(ring) < 3 || ringArea(ring) == 0 {
+		return rings
+	}
+	return append(rings, ring)
+}
+
+// ringArea returns the signed area of a ring: positive if clockwise as seen on the canvas (y grows downward)
+func ringArea(ring []Vertex) float64 {
+
+	area := 0.0
+	for i, p := range ring {
+		q := ring[(i+1)%len(ring)]
+		area += p.X*q.Y - q.X*p.Y
+	}
+	return area / 2
+}
+
+// simplifyChain simplifies a chain with the Douglas-Peucker algorithm, keeping its ends.
+// A closed loop is split in two halves at its first point and at the farthest point from it
+func simplifyChain(points []Vertex, loop bool, tolerance float64) []Vertex {
+
+	if !loop {
+		return douglasPeucker(points, tolerance)
+	}
+
+	farthest := 0
+	for i, p := range points {
+		if math.Hypot(p.X-points[0].X, p.Y-points[0].Y) > math.Hypot(points[farthest].X-points[0].X, points[farthest].Y-points[0].Y) {
+			farthest = i
+		}
+	}
+
+	closed := append(append([]Vertex{}, points...), points[0])
+	simplified := douglasPeucker(closed[:farthest+1], tolerance)
+	simplified = append(simplified, douglasPeucker(closed[farthest:], tolerance)[1:]...)
+	return simplified[:len(simplified)-1]
+}
+
+// douglasPeucker simplifies a polyline keeping its ends, so that no point is farther than the tolerance from the result
+func douglasPeucker(points []Vertex, tolerance float64) []Vertex {
+
+	if len(points) < 3 {
+		return append([]Vertex{}, points...)
+	}
+
+	first := points[0]
+	last := points[len(points)-1]
+
+	farthest := 0
+	distance := -1.0
+	for i := 1; i < len(points)-1; i++ {
+		if dist := segmentDistance(points[i], first, last); dist > distance {
+			farthest = i
+			distance = dist
+		}
+	}
+
+	// the aligned points are removed even with a zero tolerance
+	if distance <= tolerance {
+		return []Vertex{first, last}
+	}
+
+	left := douglasPeucker(points[:farthest+1], tolerance)
+	right := douglasPeucker(points[farthest:], tolerance)
+	return append(left[:len(left)-1], right...)
+}
+
+// segmentDistance returns the distance between a point and the segment from a to b
+func segmentDistance(p Vertex, a Vertex, b Vertex) float64 {
+
+	dx := b.X - a.X
+	dy := b.Y - a.Y
+	length := dx*dx + dy*dy
+	if length == 0 {
+		return math.Hypot(p.X-a.X, p.Y-a.Y)
+	}
+
+	t := math.Max(0, math.Min(1, ((p.X-a.X)*dx+(p.Y-a.Y)*dy)/length))
+	return math.Hypot(p.X-(a.X+t*dx), p.Y-(a.Y+t*dy))
+}
@@ -0,0 +1,104 @@
+package voronoi
+
+import (
+	"math"
+)
+
+// RelaxStep runs a step of the Lloyd relaxation: the tessellation is completed (if needed),
+// every seed is moved to the centroid of its cell, and the tessellation is restarted with the moved seeds.
+// The seeds are moved to the nearest pixel of the centroids, and the seeds with an empty cell stay where they are.
+// It returns the largest distance between a seed and the centroid of its cell
+func RelaxStep(e Engine) (float64, error) {
+
+	for !e.Done() {
+		if err := e.Tessellate(true); err != nil {
+			return 0, err
+		}
+	}
+
+	d := e.Diagram()
+	centroids, sizes := d.centroids()
+
+	movement := 0.0
+	seeds := append([]Point{}, d.Seeds...)
+	for i, s := range seeds {
+		if sizes[i] == 0 {
+			continue
+		}
+		movement = math.Max(movement, math.Hypot(centroids[i].X-float64(s.X), centroids[i].Y-float64(s.Y)))
+
+		seeds[i].X = int(math.Round(centroids[i].X))
+		seeds[i].Y = int(math.Round(centroids[i].Y))
+		if d.Toroidal {
+			seeds[i].X = mod(seeds[i].X, d.Width)
+			seeds[i].Y = mod(seeds[i].Y, d.Height)
+		}
+	}
+
+	return movement, e.SetSeeds(seeds)
+}
+
+/*
+Relax computes a centroidal voronoi tessellation with the Lloyd relaxation
+
+It repeats the relaxation steps (see RelaxStep) until the largest movement of the seeds
+falls below the tolerance, or until maxIterations steps are done.
+The engine is left with the tessellation of the relaxed seeds completed.
+It returns the number of steps done
+*/
+func Relax(e Engine, maxIterations int, tolerance float64) (int, error) {
+
+	iterations := 0
+	for iterations < maxIterations {
+		movement, err := RelaxStep(e)
+		if err != nil {
+			return iterations, err
+		}
+		iterations++
+
+		if movement < tolerance {
+			break
+		}
+	}
+
+	for !e.Done() {
+		if err := e.Tessellate(true); err != nil {
+			return iterations, err
+		}
+	}
+
+	return iterations, nil
+}
+
+// centroids computes the centroid of each cell, and its size (in pixels).
+// On a toroidal canvas, the pixels are taken at their shortest offset from the seed, across the borders:
+// the centroid may then lay outside the canvas
+func (d *Diagram) centroids() ([]Vertex, []int) {
+
+	centroids := make([]Vertex, len(d.Seeds))
+	sizes := make([]int, len(d.Seeds))
+
+	for pos, l := range d.Labels {
+		if l < 0 {
+			continue
+		}
+		dx := pos%d.Width - d.Seeds[l].X
+		dy := pos/d.Width - d.Seeds[l].Y
+		if d.Toroidal {
+			dx = wrapOffset(dx, d.Width)
+			dy = wrapOffset(dy, d.Height)
+		}
+		centroids[l].X += float64(dx)
+		centroids[l].Y += float64(dy)
+		sizes[l]++
+	}
+
+	for i, s := range d.Seeds {
+		if sizes[i] > 0 {
+			centroids[i].X = float64(s.X) + centroids[i].X/float64(sizes[i])
+			centroids[i].Y = float64(s.Y) + centroids[i].Y/float64(sizes[i])
+		}
+	}
+
+	return centroids, sizes
+}
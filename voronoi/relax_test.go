@@ -0,0 +1,61 @@
+package voronoi
+
+import (
+	"math"
+	"testing"
+)
+
+// TestRelax checks that the Lloyd relaxation converges before the maximum number of steps,
+// leaving every seed near the centroid of its cell and the cells more compact than the random ones
+func TestRelax(t *testing.T) {
+
+	for _, opts := range [][]Option{nil, {WithToroidal()}} {
+
+		e, err := NewVoronoi(100, 80, 20, append([]Option{WithRandSeed(10)}, opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Init()
+		for !e.Done() {
+			e.Tessellate(true)
+		}
+		before := inertia(e.Diagram())
+
+		iterations, err := Relax(e, 200, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if iterations == 200 || !e.Done() {
+			t.Fatalf("the relaxation didn't converge (%d steps, done: %v)", iterations, e.Done())
+		}
+
+		d := e.Diagram()
+		centroids, sizes := d.centroids()
+		for i, s := range d.Seeds {
+			if sizes[i] > 0 && math.Hypot(centroids[i].X-float64(s.X), centroids[i].Y-float64(s.Y)) >= 1 {
+				t.Fatalf("seed %d (%d, %d) is far from the centroid %v of its cell", i, s.X, s.Y, centroids[i])
+			}
+		}
+		if after := inertia(d); after >= before {
+			t.Fatalf("the moment of inertia of the cells grew from %g to %g", before, after)
+		}
+	}
+}
+
+// inertia returns the sum of the squared distances of the pixels from the seeds of their cells
+// (the energy minimized by the relaxation)
+func inertia(d *Diagram) float64 {
+
+	sum := 0.0
+	for pos, label := range d.Labels {
+		s := d.Seeds[label]
+		dx := math.Abs(float64(pos%d.Width - s.X))
+		dy := math.Abs(float64(pos/d.Width - s.Y))
+		if d.Toroidal {
+			dx = math.Min(dx, float64(d.Width)-dx)
+			dy = math.Min(dy, float64(d.Height)-dy)
+		}
+		sum += dx*dx + dy*dy
+	}
+	return sum
+}
@@ -0,0 +1,416 @@
+package voronoi
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ImportedSeed is a seed read from a file, with the coordinates of the file (e.g. geographic ones)
+type ImportedSeed struct {
+	X      float64
+	Y      float64
+	Color  *Color  // nil if not set in the file
+	Weight float64 // 0 if not set in the file
+	Radius float64 // 0 if not set in the file
+	Label  string
+
+	Velocity *Vertex // in the units of the coordinates per second, nil if not set in the file
+
+	Line int // line of the file the seed was read from, used to report the errors
+}
+
+// ReadSeedsFile reads the seeds from a CSV or JSON file, depending on its extension (.csv or .json)
+func ReadSeedsFile(path string) ([]ImportedSeed, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var seeds []ImportedSeed
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		seeds, err = ReadSeedsCSV(f)
+	case ".json":
+		seeds, err = ReadSeedsJSON(f)
+	default:
+		return nil, fmt.Errorf("Unknown seeds file format %q: the extension must be .csv or .json", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return seeds, nil
+}
+
+/*
+ReadSeedsCSV reads the seeds from a CSV document, one seed per row:
+
+	x,y[,r,g,b,a][,weight][,label]
+
+The color components are integers between 0 and 255, the weight is a number not negative (0 is the same as 1, unweighted).
+The first row may be a header naming the columns (x, y, r, g, b, a, weight, radius, vx, vy and label, in any order):
+in that case only the named columns are read, otherwise the columns are recognized by their position.
+The velocity of the seeds (vx and vy) can only be read with a header
+*/
+func ReadSeedsCSV(r io.Reader) ([]ImportedSeed, error) {
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	seeds := []ImportedSeed{}
+	var columns []string
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		// a first row without a numeric x is a header
+		if len(seeds) == 0 && columns == nil {
+			if _, err := strconv.ParseFloat(record[0], 64); err != nil {
+				columns, err = csvHeader(record)
+				if err != nil {
+					return nil, fmt.Errorf("Line %d: %v", line, err)
+				}
+				continue
+			}
+		}
+
+		names := columns
+		if names == nil {
+			names, err = csvPositions(record)
+			if err != nil {
+				return nil, fmt.Errorf("Line %d: %v", line, err)
+			}
+		} else if len(record) != len(names) {
+			return nil, fmt.Errorf("Line %d: expected %d columns, found %d", line, len(names), len(record))
+		}
+
+		seed, err := csvSeed(names, record)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %v", line, err)
+		}
+		seed.Line = line
+		seeds = append(seeds, seed)
+	}
+
+	return seeds, nil
+}
+
+// csvHeader validates the names of the columns of a CSV header
+func csvHeader(record []string) ([]string, error) {
+
+	columns := []string{}
+	found := map[string]bool{}
+	for _, name := range record {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "x", "y", "r", "g", "b", "a", "weight", "radius", "vx", "vy", "label":
+		default:
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		if found[name] {
+			return nil, fmt.Errorf("duplicate column %q", name)
+		}
+		found[name] = true
+		columns = append(columns, name)
+	}
+
+	if !found["x"] || !found["y"] {
+		return nil, errors.New("the x and y columns are required")
+	}
+	if found["r"] || found["g"] || found["b"] || found["a"] {
+		if !found["r"] || !found["g"] || !found["b"] || !found["a"] {
+			return nil, errors.New("the color requires all the r, g, b and a columns")
+		}
+	}
+	if found["vx"] != found["vy"] {
+		return nil, errors.New("the velocity requires both the vx and vy columns")
+	}
+	return columns, nil
+}
+
+// csvPositions recognizes the columns of a CSV row without header from their number:
+// the color takes 4 columns, the weight is the first numeric column after it, and the label is the last column
+func csvPositions(record []string) ([]string, error) {
+
+	names := []string{"x", "y"}
+	rest := len(record) - 2
+
+	if rest >= 4 {
+		names = append(names, "r", "g", "b", "a")
+		rest -= 4
+	}
+	if rest == 2 || (rest == 1 && isNumber(record[len(record)-1])) {
+		names = append(names, "weight")
+		rest--
+	}
+	if rest == 1 {
+		names = append(names, "label")
+		rest--
+	}
+
+	if rest != 0 || len(record) < 2 {
+		return nil, fmt.Errorf("expected x,y[,r,g,b,a][,weight][,label], found %d columns", len(record))
+	}
+	return names, nil
+}
+
+// csvSeed builds a seed from the values of a CSV row, given the names of its columns
+func csvSeed(names []string, record []string) (ImportedSeed, error) {
+
+	seed := ImportedSeed{}
+	var color Color
+	var velocity Vertex
+
+	for i, name := range names {
+		value := strings.TrimSpace(record[i])
+		var err error
+
+		switch name {
+		case "x":
+			seed.X, err = parseCoordinate(value)
+		case "y":
+			seed.Y, err = parseCoordinate(value)
+		case "r":
+			color.R, err = parseComponent(value)
+		case "g":
+			color.G, err = parseComponent(value)
+		case "b":
+			color.B, err = parseComponent(value)
+		case "a":
+			color.A, err = parseComponent(value)
+		case "weight":
+			seed.Weight, err = parsePositive(value)
+		case "radius":
+			seed.Radius, err = parsePositive(value)
+		case "vx":
+			velocity.X, err = parseCoordinate(value)
+		case "vy":
+			velocity.Y, err = parseCoordinate(value)
+		case "label":
+			seed.Label = record[i]
+		}
+		if err != nil {
+			return seed, fmt.Errorf("invalid %s %q", name, value)
+		}
+
+		if name == "r" {
+			seed.Color = &color
+		}
+		if name == "vx" {
+			seed.Velocity = &velocity
+		}
+	}
+
+	return seed, nil
+}
+
+// jsonSeed is a seed in a JSON seeds document
+type jsonSeed struct {
+	X      *float64 `json:"x"`
+	Y      *float64 `json:"y"`
+	Color  *Color   `json:"color"`
+	Weight float64  `json:"weight"`
+	Radius float64  `json:"radius"`
+	Label  string   `json:"label"`
+	VX     *float64 `json:"vx"`
+	VY     *float64 `json:"vy"`
+}
+
+/*
+ReadSeedsJSON reads the seeds from a JSON document, an array with an object per seed.
+Only the coordinates are required:
+
+	[
+		{"x": 10, "y": 20, "color": {"r": 255, "g": 0, "b": 0, "a": 255}, "weight": 1.5, "vx": 3, "vy": -2, "label": "first"},
+		{"x": 30.5, "y": 42}
+	]
+*/
+func ReadSeedsJSON(r io.Reader) ([]ImportedSeed, error) {
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// line of a position in the document
+	lineAt := func(offset int64) int {
+		return bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if t, err := decoder.Token(); err != nil || t != json.Delim('[') {
+		return nil, errors.New("Line 1: expected an array of seeds")
+	}
+
+	seeds := []ImportedSeed{}
+	for decoder.More() {
+
+		// the value starts at the first character after the previous one (skipping the separators)
+		start := decoder.InputOffset()
+		for start < int64(len(data)) && strings.ContainsRune(" \t\r\n,", rune(data[start])) {
+			start++
+		}
+		line := lineAt(start)
+
+		var s jsonSeed
+		if err := decoder.Decode(&s); err != nil {
+			return nil, fmt.Errorf("Line %d: %v", line, err)
+		}
+		if s.X == nil || s.Y == nil || math.IsNaN(*s.X) || math.IsNaN(*s.Y) {
+			return nil, fmt.Errorf("Line %d: the x and y coordinates are required", line)
+		}
+		if s.Weight < 0 || s.Radius < 0 {
+			return nil, fmt.Errorf("Line %d: the weight and the radius cannot be negative", line)
+		}
+		var velocity *Vertex
+		if (s.VX == nil) != (s.VY == nil) {
+			return nil, fmt.Errorf("Line %d: the velocity requires both vx and vy", line)
+		}
+		if s.VX != nil {
+			velocity = &Vertex{X: *s.VX, Y: *s.VY}
+		}
+
+		seeds = append(seeds, ImportedSeed{
+			X:      *s.X,
+			Y:      *s.Y,
+			Color:  s.Color,
+			Weight: s.Weight,
+			Radius: s.Radius,
+			Label:  s.Label,
+
+			Velocity: velocity,
+			Line:     line,
+		})
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("Line %d: %v", lineAt(decoder.InputOffset()), err)
+	}
+	return seeds, nil
+}
+
+/*
+PlaceSeeds converts the imported seeds to the seeds of a width*height diagram.
+
+If rescale is false, the coordinates of the file are pixels of the canvas (rounded to the nearest one).
+Otherwise they are scaled and translated so that their bounding box fits the canvas, keeping the aspect ratio:
+this way any coordinates (e.g. longitude and latitude) can be tessellated, and the velocities are scaled as well.
+The rescaled coordinates are cartesian, with the Y axis pointing up as on a map: the largest Y goes to the top of the canvas,
+consistently with BoundsTransform.
+Seeds outside the canvas and seeds on the same pixel are reported with their line in the file
+*/
+func PlaceSeeds(imported []ImportedSeed, width int, height int, rescale bool) ([]Point, error) {
+
+	scale := 1.0
+	offsetX := 0.0
+	offsetY := 0.0
+	flipY := 1.0 // -1 when the Y axis is reversed
+
+	if rescale && len(imported) > 0 {
+		minX, maxX := math.Inf(1), math.Inf(-1)
+		minY, maxY := math.Inf(1), math.Inf(-1)
+		for _, s := range imported {
+			minX = math.Min(minX, s.X)
+			maxX = math.Max(maxX, s.X)
+			minY = math.Min(minY, s.Y)
+			maxY = math.Max(maxY, s.Y)
+		}
+
+		// the bounding box is centered on the canvas (a single point ends up in the middle)
+		scale = math.Inf(1)
+		if maxX > minX {
+			scale = float64(width-1) / (maxX - minX)
+		}
+		if maxY > minY {
+			scale = math.Min(scale, float64(height-1)/(maxY-minY))
+		}
+		if math.IsInf(scale, 1) {
+			scale = 1
+		}
+		offsetX = float64(width-1)/2 - scale*(minX+maxX)/2
+		offsetY = float64(height-1)/2 + scale*(minY+maxY)/2
+		flipY = -1
+	}
+
+	seeds := []Point{}
+	lines := map[[2]int]int{}
+
+	for _, s := range imported {
+		x := math.Round(s.X*scale + offsetX)
+		y := math.Round(flipY*s.Y*scale + offsetY)
+		if !(x >= 0 && x < float64(width) && y >= 0 && y < float64(height)) {
+			return nil, fmt.Errorf("Line %d: the seed (%g, %g) lays outside the %dx%d canvas", s.Line, s.X, s.Y, width, height)
+		}
+
+		position := [2]int{int(x), int(y)}
+		if line, found := lines[position]; found {
+			return nil, fmt.Errorf("Line %d: the seed (%g, %g) is on the same pixel of the seed at line %d", s.Line, s.X, s.Y, line)
+		}
+		lines[position] = s.Line
+
+		seed := Point{
+			X:      position[0],
+			Y:      position[1],
+			Color:  s.Color,
+			Weight: s.Weight,
+			Radius: s.Radius,
+			Label:  s.Label,
+		}
+		if s.Velocity != nil {
+			seed.Velocity = &Vertex{X: s.Velocity.X * scale, Y: flipY * s.Velocity.Y * scale}
+		}
+		seeds = append(seeds, seed)
+	}
+
+	return seeds, nil
+}
+
+// isNumber reports whether a CSV value is a number
+func isNumber(value string) bool {
+	_, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	return err == nil
+}
+
+// parseCoordinate parses a finite coordinate
+func parseCoordinate(value string) (float64, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err == nil && (math.IsNaN(f) || math.IsInf(f, 0)) {
+		err = errors.New("not finite")
+	}
+	return f, err
+}
+
+// parseComponent parses a color component, between 0 and 255
+func parseComponent(value string) (byte, error) {
+	c, err := strconv.ParseUint(value, 10, 8)
+	return byte(c), err
+}
+
+// parsePositive parses a finite number, not negative
+func parsePositive(value string) (float64, error) {
+	f, err := parseCoordinate(value)
+	if err == nil && f < 0 {
+		err = errors.New("negative")
+	}
+	return f, err
+}
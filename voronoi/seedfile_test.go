@@ -0,0 +1,119 @@
+package voronoi
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// TestSeedsFileRoundTrip writes the seeds of a diagram to a CSV and a JSON file, and checks that reading and placing them
+// gives the same seeds, either with the coordinates of the pixels or with a map-like Y axis rescaled to the canvas
+func TestSeedsFileRoundTrip(t *testing.T) {
+
+	e, err := NewVoronoi(80, 60, 25, WithRandSeed(5), WithWeighting(Power))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Init()
+	seeds := e.Diagram().Seeds
+
+	// the corners of the canvas make the rescaled bounding box match it
+	seeds[0].X, seeds[0].Y = 0, 0
+	seeds[1].X, seeds[1].Y = 79, 59
+	for i := range seeds {
+		seeds[i].Distance = nil // not part of the files
+		seeds[i].Weight = 1 + float64(i)/8
+		if i%3 == 0 {
+			seeds[i].Label = "seed, " + strconv.Itoa(i)
+		}
+		seeds[i].Velocity = &Vertex{X: float64(i) / 4, Y: -float64(i) / 3}
+	}
+
+	for _, flipped := range []bool{false, true} {
+		for name, write := range map[string]func(string, []Point, bool) error{"seeds.csv": writeSeedsCSV, "seeds.json": writeSeedsJSON} {
+
+			path := filepath.Join(t.TempDir(), name)
+			if err := write(path, seeds, flipped); err != nil {
+				t.Fatal(err)
+			}
+
+			imported, err := ReadSeedsFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			placed, err := PlaceSeeds(imported, 80, 60, flipped)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(placed) != len(seeds) {
+				t.Fatalf("%s: %d seeds read instead of %d", name, len(placed), len(seeds))
+			}
+			for i := range seeds {
+				if !reflect.DeepEqual(placed[i], seeds[i]) {
+					t.Fatalf("%s (rescaled: %v): seed %d is %+v instead of %+v", name, flipped, i, placed[i], seeds[i])
+				}
+			}
+		}
+	}
+}
+
+// fileY returns the Y coordinate of a seed and of its velocity in a file: as the pixels, or pointing up as on a map
+func fileY(s Point, height int, flipped bool) (float64, float64) {
+
+	y, vy := float64(s.Y), s.Velocity.Y
+	if flipped {
+		return float64(height-1) - y, -vy
+	}
+	return y, vy
+}
+
+// writeSeedsCSV writes the seeds to a CSV file with a header, in the format read by ReadSeedsCSV
+func writeSeedsCSV(path string, seeds []Point, flipped bool) error {
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	format := func(f float64) string {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"label", "x", "y", "r", "g", "b", "a", "weight", "radius", "vx", "vy"})
+	for _, s := range seeds {
+		y, vy := fileY(s, 60, flipped)
+		record := []string{
+			s.Label, format(float64(s.X)), format(y),
+			strconv.Itoa(int(s.Color.R)), strconv.Itoa(int(s.Color.G)), strconv.Itoa(int(s.Color.B)), strconv.Itoa(int(s.Color.A)),
+			format(s.Weight), format(s.Radius), format(s.Velocity.X), format(vy),
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// writeSeedsJSON writes the seeds to a JSON file, in the format read by ReadSeedsJSON
+func writeSeedsJSON(path string, seeds []Point, flipped bool) error {
+
+	documents := []jsonSeed{}
+	for _, s := range seeds {
+		x := float64(s.X)
+		y, vy := fileY(s, 60, flipped)
+		vx := s.Velocity.X
+		documents = append(documents, jsonSeed{X: &x, Y: &y, Color: s.Color, Weight: s.Weight, Radius: s.Radius, Label: s.Label, VX: &vx, VY: &vy})
+	}
+
+	data, err := json.MarshalIndent(documents, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
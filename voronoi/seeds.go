@@ -0,0 +1,82 @@
+package voronoi
+
+import (
+	"math"
+	"math/rand"
+)
+
+// generateSeeds builds the seeds of a width*height diagram:
+// the explicitly configured ones if any, otherwise numSeeds random seeds with random colors,
+// placed by the seed generator and generated from the random seed of the configuration
+func generateSeeds(width int, height int, numSeeds int, c config) []Point {
+
+	seeds := []Point{}
+
+	if c.seeds != nil {
+		for _, s := range c.seeds {
+			seed := Point{
+				X:      s.X,
+				Y:      s.Y,
+				Color:  s.Color,
+				Weight: s.Weight,
+				Radius: s.Radius,
+				Label:  s.Label,
+
+				Velocity: s.Velocity,
+			}
+			d := c.distance(seed, 0, 0)
+			seed.Distance = &d
+			seeds = append(seeds, seed)
+		}
+		return seeds
+	}
+
+	// the colors and the weights are drawn from their own source, derived from the random seed:
+	// this way they don't depend on how many numbers the distribution draws to place the seeds
+	r := rand.New(rand.NewSource(c.randSeed))
+	attributes := rand.New(rand.NewSource(r.Int63()))
+	positions := c.generator.Generate(width, height, numSeeds, r)
+
+	for _, p := range positions {
+		seed := Point{
+			X: p.X,
+			Y: p.Y,
+			Color: &Color{
+				R: uint8(attributes.Intn(256)),
+				G: uint8(attributes.Intn(256)),
+				B: uint8(attributes.Intn(256)),
+				A: uint8(attributes.Intn(256)),
+			},
+		}
+		switch c.weighting {
+		case Multiplicative:
+			seed.Weight = 0.5 + 1.5*attributes.Float64()
+		case Power:
+			// the radii are comparable with the average distance between the seeds,
+			// so that some of the smaller seeds end up with an empty cell
+			seed.Radius = attributes.Float64() * 0.75 * math.Sqrt(float64(width*height)/float64(numSeeds))
+		}
+		d := c.distance(seed, 0, 0)
+		seed.Distance = &d
+
+		seeds = append(seeds, seed)
+	}
+
+	return seeds
+}
+
+// replaceSeeds validates a new set of seeds for a width*height diagram,
+// and returns them ready to be used in place of the current ones
+func replaceSeeds(width int, height int, seeds []Point, c config) ([]Point, error) {
+
+	c.seeds = append([]Point{}, seeds...)
+
+	if err := c.validateSeeds(width, height); err != nil {
+		return nil, err
+	}
+	if err := c.validateWeights(); err != nil {
+		return nil, err
+	}
+
+	return generateSeeds(width, height, len(seeds), c), nil
+}
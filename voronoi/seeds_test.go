@@ -0,0 +1,30 @@
+package voronoi
+
+import (
+	"testing"
+)
+
+// TestSeedAttributes checks that the colors and the weights of the random seeds only depend on the random seed,
+// whatever distribution places them
+func TestSeedAttributes(t *testing.T) {
+
+	for _, weighting := range []Weighting{Multiplicative, Power} {
+		c := newConfig([]Option{WithRandSeed(42), WithWeighting(weighting)})
+		uniform := generateSeeds(200, 150, 40, c)
+
+		for _, g := range SeedGenerators() {
+			c.generator = g
+			seeds := generateSeeds(200, 150, 40, c)
+			if len(seeds) == 0 {
+				t.Fatalf("%s: no seeds generated", g)
+			}
+
+			for i, s := range seeds {
+				if *s.Color != *uniform[i].Color || s.Weight != uniform[i].Weight || s.Radius != uniform[i].Radius {
+					t.Fatalf("%s, %v weighting: seed %d has color %v, weight %g and radius %g instead of %v, %g and %g",
+						g, weighting, i, *s.Color, s.Weight, s.Radius, *uniform[i].Color, uniform[i].Weight, uniform[i].Radius)
+				}
+			}
+		}
+	}
+}
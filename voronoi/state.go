@@ -0,0 +1,229 @@
+package voronoi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// StateVersion is the version of the state documents written by this package.
+// It changes every time the schema changes in a way older readers can't handle
+const StateVersion = 1
+
+/*
+State is everything needed to reproduce or post-process a diagram:
+the settings of the engine, the seeds and a summary of each cell.
+
+It is exported as a JSON document, whose version field identifies the schema:
+
+	{
+		"version": 1,
+		"width": 500,
+		"height": 500,
+		"algorithm": "wavefront",
+		"metric": "euclidean",
+		"weighting": "none",
+		"toroidal": false,
+		"rand-seed": 42,
+		"seeds": [{"x": 10, "y": 20, "color": {"r": 255, "g": 0, "b": 0, "a": 255}, "label": "first"}, ...],
+		"cells": [{"seed": 0, "area": 1234, "centroid": {"x": 12.5, "y": 21.3}, "neighbors": [3, 7]}, ...]
+	}
+*/
+type State struct {
+	Version int `json:"version"`
+
+	// diagram size (in pixels)
+	Width  int `json:"width"`
+	Height int `json:"height"`
+
+	// settings of the engine
+	Algorithm     Algorithm `json:"algorithm"`
+	Metric        string    `json:"metric"`
+	P             float64   `json:"p,omitempty"` // order of the minkowski metric
+	Weighting     string    `json:"weighting"`
+	Toroidal      bool      `json:"toroidal"`
+	JFACorrection int       `json:"jfa-correction,omitempty"`
+	RandSeed      int64     `json:"rand-seed"` // random seed the seeds were generated from (meaningless for explicit seeds)
+
+	Seeds []StateSeed `json:"seeds"`
+
+	// summary of the cell of each seed, in the same order of the seeds.
+	// It is ignored when the state is loaded, as the diagram is computed again
+	Cells []CellSummary `json:"cells"`
+}
+
+// StateSeed is a seed in a state document
+type StateSeed struct {
+	X      int     `json:"x"`
+	Y      int     `json:"y"`
+	Color  *Color  `json:"color,omitempty"`
+	Weight float64 `json:"weight,omitempty"`
+	Radius float64 `json:"radius,omitempty"`
+	Label  string  `json:"label,omitempty"`
+
+	Velocity *Vertex `json:"velocity,omitempty"` // in pixels per second
+}
+
+// CellSummary describes the cell of a seed
+type CellSummary struct {
+	Seed      int     `json:"seed"`      // index of the seed
+	Area      int     `json:"area"`      // size of the cell (in pixels)
+	Centroid  *Vertex `json:"centroid"`  // centroid of the cell (nil if the cell is empty)
+	Neighbors []int   `json:"neighbors"` // indexes of the seeds whose cells share an edge with this cell
+}
+
+// NewState captures the state of an engine, completing its tessellation first (if needed)
+func NewState(e Engine) (*State, error) {
+
+	algorithm, c, err := engineSettings(e)
+	if err != nil {
+		return nil, err
+	}
+
+	metric := c.metric.String()
+	p := 0.0
+	switch m := c.metric.(type) {
+	case Euclidean, Manhattan, Chebyshev:
+	case Minkowski:
+		metric = "minkowski"
+		p = m.P
+	default:
+		return nil, fmt.Errorf("The metric %s cannot be exported", metric)
+	}
+
+	for !e.Done() {
+		if err := e.Tessellate(true); err != nil {
+			return nil, err
+		}
+	}
+	d := e.Diagram()
+
+	s := &State{
+		Version:       StateVersion,
+		Width:         d.Width,
+		Height:        d.Height,
+		Algorithm:     algorithm,
+		Metric:        metric,
+		P:             p,
+		Weighting:     c.weighting.String(),
+		Toroidal:      c.toroidal,
+		JFACorrection: c.jfaCorrection,
+		RandSeed:      e.RandSeed(),
+		Seeds:         []StateSeed{},
+		Cells:         []CellSummary{},
+	}
+
+	centroids, sizes := d.centroids()
+	neighbors := d.Neighbors()
+
+	for i, seed := range d.Seeds {
+		s.Seeds = append(s.Seeds, StateSeed{
+			X:      seed.X,
+			Y:      seed.Y,
+			Color:  seed.Color,
+			Weight: seed.Weight,
+			Radius: seed.Radius,
+			Label:  seed.Label,
+
+			Velocity: seed.Velocity,
+		})
+
+		cell := CellSummary{
+			Seed:      i,
+			Area:      sizes[i],
+			Neighbors: neighbors[i],
+		}
+		if sizes[i] > 0 {
+			centroid := centroids[i]
+			cell.Centroid = &centroid
+		}
+		s.Cells = append(s.Cells, cell)
+	}
+
+	return s, nil
+}
+
+// engineSettings returns the algorithm and the configuration of an engine of this package
+func engineSettings(e Engine) (Algorithm, config, error) {
+
+	switch engine := e.(type) {
+	case *Voronoi:
+		return AlgorithmWavefront, engine.config, nil
+	case *Fortune:
+		return AlgorithmFortune, engine.config, nil
+	case *BruteForce:
+		return AlgorithmBruteForce, engine.config, nil
+	case *JFA:
+		return AlgorithmJFA, engine.config, nil
+	case *EDT:
+		return AlgorithmEDT, engine.config, nil
+	default:
+		return "", config{}, fmt.Errorf("Unknown engine %T", e)
+	}
+}
+
+// WriteJSON writes the state as an indented JSON document
+func (s *State) WriteJSON(w io.Writer) error {
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(s)
+}
+
+// ReadState reads a state document, checking that its version is supported
+func ReadState(r io.Reader) (*State, error) {
+
+	s := &State{}
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, fmt.Errorf("Invalid state document: %v", err)
+	}
+	if s.Version < 1 || s.Version > StateVersion {
+		return nil, fmt.Errorf("Unsupported version %d of the state document (the latest supported version is %d)", s.Version, StateVersion)
+	}
+	return s, nil
+}
+
+// Points returns the seeds of the state, ready for WithSeeds
+func (s *State) Points() []Point {
+
+	seeds := []Point{}
+	for _, seed := range s.Seeds {
+		seeds = append(seeds, Point{
+			X:      seed.X,
+			Y:      seed.Y,
+			Color:  seed.Color,
+			Weight: seed.Weight,
+			Radius: seed.Radius,
+			Label:  seed.Label,
+
+			Velocity: seed.Velocity,
+		})
+	}
+	return seeds
+}
+
+// NewEngine creates an engine that computes the diagram of the state again, with the same settings and seeds
+func (s *State) NewEngine() (Engine, error) {
+
+	metric, err := ParseMetric(s.Metric, s.P)
+	if err != nil {
+		return nil, err
+	}
+	weighting, err := ParseWeighting(s.Weighting)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []Option{
+		WithSeeds(s.Points()),
+		WithMetric(metric),
+		WithWeighting(weighting),
+		WithRandSeed(s.RandSeed),
+		WithJFACorrection(s.JFACorrection),
+	}
+	if s.Toroidal {
+		opts = append(opts, WithToroidal())
+	}
+
+	return New(s.Algorithm, s.Width, s.Height, len(s.Seeds), opts...)
+}
@@ -0,0 +1,83 @@
+package voronoi
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestStateRoundTrip saves the state of each backend as a JSON document and loads it again:
+// the document must be read back unchanged, and the engine it creates must compute the same diagram
+func TestStateRoundTrip(t *testing.T) {
+
+	cases := []struct {
+		algorithm Algorithm
+		opts      []Option
+	}{
+		{AlgorithmWavefront, []Option{WithMetric(Minkowski{P: 3}), WithToroidal()}},
+		{AlgorithmWavefront, []Option{WithWeighting(Multiplicative)}},
+		{AlgorithmFortune, nil},
+		{AlgorithmBruteForce, []Option{WithMetric(Manhattan{}), WithWeighting(Multiplicative), WithToroidal()}},
+		{AlgorithmJFA, []Option{WithJFACorrection(2), WithMetric(Chebyshev{})}},
+		{AlgorithmEDT, []Option{WithWeighting(Power), WithToroidal()}},
+	}
+
+	for _, c := range cases {
+		e, err := New(c.algorithm, 90, 70, 30, append([]Option{WithRandSeed(5)}, c.opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Init()
+
+		// labels and velocities only come from explicit seeds
+		seeds := e.Diagram().Seeds
+		seeds[0].Label = "first \"seed\""
+		seeds[1].Velocity = &Vertex{X: 2.5, Y: -1}
+		if err := e.SetSeeds(seeds); err != nil {
+			t.Fatal(err)
+		}
+
+		s, err := NewState(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := s.WriteJSON(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := ReadState(&buf)
+		if err != nil {
+			t.Fatalf("%s: %v", c.algorithm, err)
+		}
+		if !reflect.DeepEqual(loaded, s) {
+			t.Fatalf("%s: the state is read as %+v instead of %+v", c.algorithm, loaded, s)
+		}
+
+		restored, err := loaded.NewEngine()
+		if err != nil {
+			t.Fatalf("%s: %v", c.algorithm, err)
+		}
+		restored.Init()
+		again, err := NewState(restored)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(again, s) {
+			t.Fatalf("%s: the loaded state gives %+v instead of %+v", c.algorithm, again, s)
+		}
+		if !reflect.DeepEqual(restored.Diagram().Labels, e.Diagram().Labels) {
+			t.Fatalf("%s: the loaded state gives a different diagram", c.algorithm)
+		}
+	}
+}
+
+// TestStateVersion checks that the documents of unknown versions are rejected
+func TestStateVersion(t *testing.T) {
+	for _, document := range []string{`{"version": 0}`, `{"version": 2}`, `{}`} {
+		if _, err := ReadState(strings.NewReader(document)); err == nil {
+			t.Errorf("%s: the document is accepted", document)
+		}
+	}
+}
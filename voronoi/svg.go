@@ -0,0 +1,100 @@
+package voronoi
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SVGOptions customizes the SVG export of a diagram
+type SVGOptions struct {
+	StrokeWidth float64 // width of the outlines of the cells (0 for no outlines)
+	Tolerance   float64 // maximum distance between the simplified outlines and the borders of the pixels (see Polygons)
+	SeedRadius  float64 // radius of the circles drawn on the seeds (0 to hide the seeds)
+}
+
+// DefaultSVGOptions returns the options matching the look of the viewer: thin outlines, small seeds,
+// and outlines simplified within a pixel
+func DefaultSVGOptions() SVGOptions {
+	return SVGOptions{
+		StrokeWidth: 1,
+		Tolerance:   1,
+		SeedRadius:  1.5,
+	}
+}
+
+/*
+WriteSVG exports the diagram as an SVG image, with a path for each cell filled with the color of its seed
+(black for the seeds without a color, opaque as in the viewer) and a circle for each seed.
+
+The outlines of the cells are traced along the borders of the pixels and simplified (see Polygons),
+so that the file stays small even with thousands of cells. The label of each seed becomes the title of its cell.
+With the power weighting, the circle of each seed is drawn as well
+*/
+func (d *Diagram) WriteSVG(w io.Writer, o SVGOptions) error {
+
+	b := bufio.NewWriter(w)
+
+	fmt.Fprintf(b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", d.Width, d.Height, d.Width, d.Height)
+
+	stroke := "stroke=\"none\""
+	if o.StrokeWidth > 0 {
+		stroke = fmt.Sprintf("stroke=\"black\" stroke-width=\"%g\" stroke-linejoin=\"round\"", o.StrokeWidth)
+	}
+	fmt.Fprintf(b, "<g fill-rule=\"evenodd\" %s>\n", stroke)
+
+	for _, p := range d.Polygons(o.Tolerance) {
+		if len(p.Rings) == 0 {
+			continue
+		}
+
+		// the holes are filled by the cells inside them, thanks to the even-odd rule
+		var path strings.Builder
+		for _, ring := range p.Rings {
+			for i, v := range ring {
+				command := "L"
+				if i == 0 {
+					command = "M"
+				}
+				fmt.Fprintf(&path, "%s%g %g", command, v.X, v.Y)
+			}
+			path.WriteString("Z")
+		}
+
+		seed := d.Seeds[p.Seed]
+		fill := "rgb(0,0,0)"
+		if seed.Color != nil {
+			fill = fmt.Sprintf("rgb(%d,%d,%d)", seed.Color.R, seed.Color.G, seed.Color.B)
+		}
+
+		if seed.Label == "" {
+			fmt.Fprintf(b, "<path d=\"%s\" fill=\"%s\"/>\n", path.String(), fill)
+			continue
+		}
+		fmt.Fprintf(b, "<path d=\"%s\" fill=\"%s\"><title>", path.String(), fill)
+		if err := xml.EscapeText(b, []byte(seed.Label)); err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "</title></path>\n")
+	}
+	fmt.Fprintf(b, "</g>\n")
+
+	// the seeds are drawn in the middle of their pixels
+	if o.SeedRadius > 0 || d.Weighting == Power {
+		fmt.Fprintf(b, "<g fill=\"black\">\n")
+		for _, s := range d.Seeds {
+			if o.SeedRadius > 0 {
+				fmt.Fprintf(b, "<circle cx=\"%g\" cy=\"%g\" r=\"%g\"/>\n", float64(s.X)+0.5, float64(s.Y)+0.5, o.SeedRadius)
+			}
+			if d.Weighting == Power && s.Radius > 0 {
+				fmt.Fprintf(b, "<circle cx=\"%g\" cy=\"%g\" r=\"%g\" fill=\"none\" stroke=\"black\"/>\n", float64(s.X)+0.5, float64(s.Y)+0.5, s.Radius)
+			}
+		}
+		fmt.Fprintf(b, "</g>\n")
+	}
+
+	fmt.Fprintf(b, "</svg>\n")
+	return b.Flush()
+}
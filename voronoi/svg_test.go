@@ -0,0 +1,149 @@
+package voronoi
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// svgDocument is the part of an SVG image written by WriteSVG read back by the tests
+type svgDocument struct {
+	Groups []struct {
+		Paths []struct {
+			D     string `xml:"d,attr"`
+			Fill  string `xml:"fill,attr"`
+			Title string `xml:"title"`
+		} `xml:"path"`
+	} `xml:"g"`
+}
+
+// TestSVGRoundTrip exports diagrams as SVG images without simplifying the outlines, and checks that filling their paths
+// (with the even-odd rule) paints every pixel with the color of its cell, and that the labels of the seeds are the titles
+func TestSVGRoundTrip(t *testing.T) {
+
+	for _, d := range exportedDiagrams(t) {
+
+		var buf bytes.Buffer
+		if err := d.WriteSVG(&buf, SVGOptions{StrokeWidth: 1, SeedRadius: 1.5}); err != nil {
+			t.Fatal(err)
+		}
+		var document svgDocument
+		if err := xml.Unmarshal(buf.Bytes(), &document); err != nil {
+			t.Fatal(err)
+		}
+
+		// the cells are recognized by their colors, all different
+		seeds := map[string]int{}
+		for i, s := range d.Seeds {
+			seeds[fmt.Sprintf("rgb(%d,%d,%d)", s.Color.R, s.Color.G, s.Color.B)] = i
+		}
+
+		cells := map[int][][]Vertex{}
+		for _, path := range document.Groups[0].Paths {
+			seed, found := seeds[path.Fill]
+			if !found {
+				t.Fatalf("unknown fill %s", path.Fill)
+			}
+			if path.Title != d.Seeds[seed].Label {
+				t.Fatalf("the cell of seed %d has title %q instead of %q", seed, path.Title, d.Seeds[seed].Label)
+			}
+			rings, err := parsePath(path.D)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cells[seed] = rings
+		}
+
+		if diff := paintCells(d, cells, Affine{A: 1, E: 1}); diff != "" {
+			t.Fatal(diff)
+		}
+	}
+}
+
+// parsePath reads the rings of an SVG path made of absolute moves and lines, each ring closed by a Z
+func parsePath(d string) ([][]Vertex, error) {
+
+	rings := [][]Vertex{}
+	for _, ring := range strings.Split(strings.TrimSuffix(d, "Z"), "Z") {
+		if !strings.HasPrefix(ring, "M") {
+			return nil, fmt.Errorf("Invalid ring %q", ring)
+		}
+		vertices := []Vertex{}
+		for _, command := range strings.FieldsFunc(ring, func(r rune) bool { return r == 'M' || r == 'L' }) {
+			var v Vertex
+			coordinates := strings.Fields(command)
+			if len(coordinates) != 2 {
+				return nil, fmt.Errorf("Invalid ring %q", ring)
+			}
+			var err error
+			if v.X, err = strconv.ParseFloat(coordinates[0], 64); err != nil {
+				return nil, err
+			}
+			if v.Y, err = strconv.ParseFloat(coordinates[1], 64); err != nil {
+				return nil, err
+			}
+			vertices = append(vertices, v)
+		}
+		rings = append(rings, vertices)
+	}
+	return rings, nil
+}
+
+// exportedDiagrams returns the diagrams the exports are tested on: with distinct colors and some labels,
+// and with cells split across the borders of a toroidal canvas or surrounding other cells with the multiplicative weighting
+func exportedDiagrams(t *testing.T) []*Diagram {
+
+	diagrams := []*Diagram{}
+	for _, opts := range [][]Option{nil, {WithWeighting(Multiplicative), WithToroidal()}} {
+
+		e, err := NewBruteForce(90, 70, 30, append([]Option{WithRandSeed(9)}, opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Init()
+		seeds := e.Diagram().Seeds
+		for i := range seeds {
+			seeds[i].Color = &Color{R: uint8(i), G: uint8(255 - i), B: uint8(7 * i), A: 255}
+			if i%4 == 0 {
+				seeds[i].Label = fmt.Sprintf("<seed %d> & co.", i)
+			}
+		}
+		if err := e.SetSeeds(seeds); err != nil {
+			t.Fatal(err)
+		}
+		e.Tessellate(true)
+		diagrams = append(diagrams, e.Diagram())
+	}
+	return diagrams
+}
+
+// paintCells checks that the center of each pixel of a diagram, transformed to the coordinates of the exported rings,
+// lays inside the rings of its cell (with the even-odd rule) and of no other cell.
+// It returns a description of the first pixel painted wrong (an empty string if there are none)
+func paintCells(d *Diagram, cells map[int][][]Vertex, transform Affine) string {
+
+	for pos, label := range d.Labels {
+		x, y := pos%d.Width, pos/d.Width
+		center := transform.Apply(Vertex{X: float64(x) + 0.5, Y: float64(y) + 0.5})
+
+		painted := []int{}
+		for seed, rings := range cells {
+			inside := false
+			for _, ring := range rings {
+				if insideRing(center, ring) {
+					inside = !inside
+				}
+			}
+			if inside {
+				painted = append(painted, seed)
+			}
+		}
+		if len(painted) != 1 || painted[0] != label {
+			return fmt.Sprintf("(%d, %d) is painted by the cells %v instead of %d", x, y, painted, label)
+		}
+	}
+	return ""
+}
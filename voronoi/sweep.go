@@ -0,0 +1,909 @@
+package voronoi
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// epsilon is the tolerance used by the Fortune's algorithm to compare coordinates
+const epsilon = 1e-9
+
+// site is an input point of the Fortune's algorithm
+type site struct {
+	x     float64
+	y     float64
+	index int // index of the seed generating the site
+}
+
+// sweepVertex is a vertex found by the Fortune's algorithm
+type sweepVertex struct {
+	x float64
+	y float64
+}
+
+// sweepEdge is an edge found by the Fortune's algorithm, separating the cells of lSite and rSite.
+// Its endpoints (va, vb) are nil until the edge gets closed (or clipped) on that side.
+// The edges running along the border of the bounding box have no rSite
+type sweepEdge struct {
+	lSite *site
+	rSite *site
+	va    *sweepVertex
+	vb    *sweepVertex
+}
+
+// sweepHalfEdge is the side of an edge facing a cell
+type sweepHalfEdge struct {
+	site  *site // site of the cell the half-edge belongs to
+	edge  *sweepEdge
+	angle float64 // angle used to sort the half-edges around the site
+}
+
+// start returns the first vertex of the half-edge, walking around its cell
+func (h *sweepHalfEdge) start() *sweepVertex {
+	if h.edge.lSite == h.site {
+		return h.edge.va
+	}
+	return h.edge.vb
+}
+
+// end returns the last vertex of the half-edge, walking around its cell
+func (h *sweepHalfEdge) end() *sweepVertex {
+	if h.edge.lSite == h.site {
+		return h.edge.vb
+	}
+	return h.edge.va
+}
+
+// sweepCell is the cell of a site, as a list of half-edges
+type sweepCell struct {
+	site      *site
+	halfEdges []*sweepHalfEdge
+	closeMe   bool // true if the cell touches the bounding box and must be closed along its border
+}
+
+// circleEvent is the event of the Fortune's algorithm that makes an arc of the beach line disappear
+type circleEvent struct {
+	arc     *arc
+	x       float64 // x of the center of the circle
+	y       float64 // lowest y of the circle, where the event is triggered
+	yCenter float64 // y of the center of the circle
+	seq     int     // insertion sequence number, used to order events with the same position
+	index   int     // position in the queue
+}
+
+// circleQueue is the priority queue of the circle events, ordered by position
+type circleQueue []*circleEvent
+
+func (q circleQueue) Len() int { return len(q) }
+
+func (q circleQueue) Less(i, j int) bool {
+	if q[i].y != q[j].y {
+		return q[i].y < q[j].y
+	}
+	if q[i].x != q[j].x {
+		return q[i].x < q[j].x
+	}
+	// among identical events, the most recent comes first
+	return q[i].seq > q[j].seq
+}
+
+func (q circleQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *circleQueue) Push(x interface{}) {
+	e := x.(*circleEvent)
+	e.index = len(*q)
+	*q = append(*q, e)
+}
+
+func (q *circleQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	e.index = -1
+	return e
+}
+
+// bbox is the bounding box the diagram is clipped to
+type bbox struct {
+	xl float64 // left
+	xr float64 // right
+	yt float64 // top
+	yb float64 // bottom
+}
+
+/*
+sweep implements the Fortune's algorithm
+
+A horizontal sweep line moves downward (towards growing y) over the sites,
+and the beach line (the set of parabolic arcs of the points equidistant
+from a site and the sweep line) traces the edges of the diagram with its breakpoints.
+A site event adds an arc to the beach line, a circle event removes an arc squeezed
+between its neighbours, creating a vertex of the diagram.
+
+When the sweep is done, the edges are connected to (and clipped against) the bounding box,
+and the cells touching the border are closed along it.
+*/
+type sweep struct {
+	beachLine beachLine
+	circles   circleQueue
+	seq       int // sequence number of the next circle event
+
+	cells []*sweepCell // cells, indexed by seed
+	edges []*sweepEdge
+
+	triangles [][3]int // triangles of the dual Delaunay triangulation, one for each vertex of the diagram (site indexes)
+}
+
+// computeSweep runs the Fortune's algorithm on the given sites,
+// clipping the resulting diagram to the bounding box.
+// numCells is the size of the list of cells, that is indexed by the site index
+func computeSweep(sites []*site, numCells int, box bbox) *sweep {
+
+	s := &sweep{
+		cells: make([]*sweepCell, numCells),
+	}
+
+	// sort the site events from top to bottom, and from left to right
+	// (the duplicated sites by index, so the first one is kept)
+	events := append([]*site{}, sites...)
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].y != events[j].y {
+			return events[i].y < events[j].y
+		}
+		if events[i].x != events[j].x {
+			return events[i].x < events[j].x
+		}
+		return events[i].index < events[j].index
+	})
+
+	var last *site
+	for next := 0; ; {
+
+		circle := s.firstCircle()
+
+		if next < len(events) &&
+			(circle == nil ||
+				events[next].y < circle.y ||
+				(events[next].y == circle.y && events[next].x < circle.x)) {
+
+			// site event (duplicated sites are ignored)
+			st := events[next]
+			if last == nil || st.x != last.x || st.y != last.y {
+				s.cells[st.index] = &sweepCell{site: st}
+				s.addArc(st)
+				last = st
+			}
+			next++
+
+		} else if circle != nil {
+			// circle event
+			s.removeArc(circle.arc)
+
+		} else {
+			break
+		}
+	}
+
+	s.clipEdges(box)
+	s.closeCells(box)
+
+	// with a single site (no edges at all) the cell is the whole bounding box
+	if len(s.edges) == 0 {
+		for _, cell := range s.cells {
+			if cell != nil {
+				s.boxCell(cell, box)
+			}
+		}
+	}
+
+	return s
+}
+
+// boxCell makes the cell coincide with the bounding box
+func (s *sweep) boxCell(cell *sweepCell, box bbox) {
+
+	corners := []*sweepVertex{
+		{x: box.xl, y: box.yt},
+		{x: box.xl, y: box.yb},
+		{x: box.xr, y: box.yb},
+		{x: box.xr, y: box.yt},
+	}
+
+	for i := range corners {
+		edge := &sweepEdge{lSite: cell.site, va: corners[i], vb: corners[(i+1)%len(corners)]}
+		s.edges = append(s.edges, edge)
+		cell.halfEdges = append(cell.halfEdges, newSweepHalfEdge(edge, cell.site, nil))
+	}
+}
+
+// firstCircle returns the next circle event (nil if there are none)
+func (s *sweep) firstCircle() *circleEvent {
+	if len(s.circles) == 0 {
+		return nil
+	}
+	return s.circles[0]
+}
+
+// createEdge creates an edge between two sites, with the given endpoints (if any)
+func (s *sweep) createEdge(lSite *site, rSite *site, va *sweepVertex, vb *sweepVertex) *sweepEdge {
+
+	edge := &sweepEdge{lSite: lSite, rSite: rSite}
+	s.edges = append(s.edges, edge)
+
+	if va != nil {
+		setEdgeStartpoint(edge, lSite, rSite, va)
+	}
+	if vb != nil {
+		setEdgeStartpoint(edge, rSite, lSite, vb)
+	}
+
+	s.cells[lSite.index].halfEdges = append(s.cells[lSite.index].halfEdges, newSweepHalfEdge(edge, lSite, rSite))
+	s.cells[rSite.index].halfEdges = append(s.cells[rSite.index].halfEdges, newSweepHalfEdge(edge, rSite, lSite))
+
+	return edge
+}
+
+// setEdgeStartpoint sets the endpoint of the edge on the side of the first site (lSite)
+func setEdgeStartpoint(edge *sweepEdge, lSite *site, rSite *site, vertex *sweepVertex) {
+	if edge.va == nil && edge.vb == nil {
+		edge.va = vertex
+		edge.lSite = lSite
+		edge.rSite = rSite
+	} else if edge.lSite == rSite {
+		edge.vb = vertex
+	} else {
+		edge.va = vertex
+	}
+}
+
+// newSweepHalfEdge creates the half-edge facing the cell of lSite.
+// For the edges along the border of the bounding box (rSite is nil) the angle is computed from the endpoints
+func newSweepHalfEdge(edge *sweepEdge, lSite *site, rSite *site) *sweepHalfEdge {
+
+	h := &sweepHalfEdge{site: lSite, edge: edge}
+
+	if rSite != nil {
+		h.angle = math.Atan2(rSite.y-lSite.y, rSite.x-lSite.x)
+	} else if edge.lSite == lSite {
+		h.angle = math.Atan2(edge.vb.x-edge.va.x, edge.va.y-edge.vb.y)
+	} else {
+		h.angle = math.Atan2(edge.va.x-edge.vb.x, edge.vb.y-edge.va.y)
+	}
+
+	return h
+}
+
+// leftBreakPoint computes the x of the breakpoint on the left of the arc, given the sweep line position
+func leftBreakPoint(a *arc, directrix float64) float64 {
+
+	rfocx := a.site.x
+	rfocy := a.site.y
+	pby2 := rfocy - directrix
+
+	// the parabola is degenerate (vertical line) if the focus lays on the directrix
+	if pby2 == 0 {
+		return rfocx
+	}
+
+	left := a.previous
+	if left == nil {
+		return math.Inf(-1)
+	}
+
+	lfocx := left.site.x
+	lfocy := left.site.y
+	plby2 := lfocy - directrix
+	if plby2 == 0 {
+		return lfocx
+	}
+
+	hl := lfocx - rfocx
+	aby2 := 1/pby2 - 1/plby2
+	b := hl / plby2
+	if aby2 != 0 {
+		return (-b+math.Sqrt(b*b-2*aby2*(hl*hl/(-2*plby2)-lfocy+plby2/2+rfocy-pby2/2)))/aby2 + rfocx
+	}
+
+	// both parabolas have the same distance from the directrix: the breakpoint is in the middle
+	return (rfocx + lfocx) / 2
+}
+
+// rightBreakPoint computes the x of the breakpoint on the right of the arc, given the sweep line position
+func rightBreakPoint(a *arc, directrix float64) float64 {
+	if a.next != nil {
+		return leftBreakPoint(a.next, directrix)
+	}
+	if a.site.y == directrix {
+		return a.site.x
+	}
+	return math.Inf(1)
+}
+
+// addArc handles a site event, splitting the arc above the site
+func (s *sweep) addArc(st *site) {
+
+	x := st.x
+	directrix := st.y
+
+	// find the arc(s) right above the new site
+	var lArc, rArc *arc
+	node := s.beachLine.root
+	for node != nil {
+		dxl := leftBreakPoint(node, directrix) - x
+
+		if dxl > epsilon {
+			// the site is on the left of the arc
+			node = node.left
+			continue
+		}
+
+		dxr := x - rightBreakPoint(node, directrix)
+		if dxr > epsilon {
+			// the site is on the right of the arc
+			if node.right == nil {
+				lArc = node
+				break
+			}
+			node = node.right
+			continue
+		}
+
+		if dxl > -epsilon {
+			// the site falls on the left breakpoint
+			lArc = node.previous
+			rArc = node
+		} else if dxr > -epsilon {
+			// the site falls on the right breakpoint
+			lArc = node
+			rArc = node.next
+		} else {
+			// the site falls in the middle of the arc
+			lArc = node
+			rArc = node
+		}
+		break
+	}
+
+	newArc := &arc{site: st}
+	s.beachLine.insert(lArc, newArc)
+
+	// first arc of the beach line
+	if lArc == nil && rArc == nil {
+		return
+	}
+
+	// the new arc splits an existing arc in two
+	if lArc == rArc {
+		s.detachCircle(lArc)
+
+		rArc = &arc{site: lArc.site}
+		s.beachLine.insert(newArc, rArc)
+
+		edge := s.createEdge(lArc.site, newArc.site, nil, nil)
+		newArc.edge = edge
+		rArc.edge = edge
+
+		s.attachCircle(lArc)
+		s.attachCircle(rArc)
+		return
+	}
+
+	// the new arc is the last one of the beach line
+	// (it happens only while all the sites processed so far have the same y)
+	if rArc == nil {
+		newArc.edge = s.createEdge(lArc.site, newArc.site, nil, nil)
+		return
+	}
+
+	// the new site falls exactly on a breakpoint: the edge between lArc and rArc ends here,
+	// and two new edges start from the same vertex
+	s.detachCircle(lArc)
+	s.detachCircle(rArc)
+
+	lSite := lArc.site
+	ax := lSite.x
+	ay := lSite.y
+	bx := st.x - ax
+	by := st.y - ay
+	rSite := rArc.site
+	cx := rSite.x - ax
+	cy := rSite.y - ay
+	d := 2 * (bx*cy - by*cx)
+	hb := bx*bx + by*by
+	hc := cx*cx + cy*cy
+	vertex := &sweepVertex{x: (cy*hb-by*hc)/d + ax, y: (bx*hc-cx*hb)/d + ay}
+
+	setEdgeStartpoint(rArc.edge, lSite, rSite, vertex)
+	s.addTriangle(lSite, st, rSite)
+
+	newArc.edge = s.createEdge(lSite, st, nil, vertex)
+	rArc.edge = s.createEdge(st, rSite, nil, vertex)
+
+	s.attachCircle(lArc)
+	s.attachCircle(rArc)
+}
+
+// removeArc handles a circle event, removing the arc (and the arcs collapsing in the same point)
+// and creating a vertex of the diagram
+func (s *sweep) removeArc(a *arc) {
+
+	circle := a.circle
+	x := circle.x
+	y := circle.yCenter
+	vertex := &sweepVertex{x: x, y: y}
+
+	previous := a.previous
+	next := a.next
+	disappearing := []*arc{a}
+
+	s.detachArc(a)
+
+	// look for the arcs on the left collapsing in the same point
+	lArc := previous
+	for lArc.circle != nil &&
+		math.Abs(x-lArc.circle.x) < epsilon &&
+		math.Abs(y-lArc.circle.yCenter) < epsilon {
+
+		previous = lArc.previous
+		disappearing = append([]*arc{lArc}, disappearing...)
+		s.detachArc(lArc)
+		lArc = previous
+	}
+	disappearing = append([]*arc{lArc}, disappearing...)
+	s.detachCircle(lArc)
+
+	// look for the arcs on the right collapsing in the same point
+	rArc := next
+	for rArc.circle != nil &&
+		math.Abs(x-rArc.circle.x) < epsilon &&
+		math.Abs(y-rArc.circle.yCenter) < epsilon {
+
+		next = rArc.next
+		disappearing = append(disappearing, rArc)
+		s.detachArc(rArc)
+		rArc = next
+	}
+	disappearing = append(disappearing, rArc)
+	s.detachCircle(rArc)
+
+	// the edges between the disappearing arcs end in the new vertex
+	for i := 1; i < len(disappearing); i++ {
+		setEdgeStartpoint(disappearing[i].edge, disappearing[i-1].site, disappearing[i].site, vertex)
+	}
+
+	// the sites of the arcs are on the same circle, in order around the vertex:
+	// they are the corners of a convex polygon of the triangulation, split in a fan of triangles
+	for i := 2; i < len(disappearing); i++ {
+		s.addTriangle(disappearing[0].site, disappearing[i-1].site, disappearing[i].site)
+	}
+
+	// a new edge starts from the vertex, between the two arcs surviving the event
+	lArc = disappearing[0]
+	rArc = disappearing[len(disappearing)-1]
+	rArc.edge = s.createEdge(lArc.site, rArc.site, nil, vertex)
+
+	s.attachCircle(lArc)
+	s.attachCircle(rArc)
+}
+
+// addTriangle records a triangle of the Delaunay triangulation,
+// with its corners ordered counterclockwise as seen on the canvas (where y grows downward)
+func (s *sweep) addTriangle(a *site, b *site, c *site) {
+
+	cross := (b.x-a.x)*(c.y-a.y) - (b.y-a.y)*(c.x-a.x)
+	if a.index == b.index || b.index == c.index || a.index == c.index || math.Abs(cross) < epsilon {
+		return
+	}
+
+	// on the canvas, a positive cross product means the corners are clockwise
+	if cross > 0 {
+		b, c = c, b
+	}
+	s.triangles = append(s.triangles, [3]int{a.index, b.index, c.index})
+}
+
+// detachArc removes an arc from the beach line, along with its circle event
+func (s *sweep) detachArc(a *arc) {
+	s.detachCircle(a)
+	s.beachLine.remove(a)
+}
+
+// attachCircle creates the circle event of the arc, if its neighbours converge
+func (s *sweep) attachCircle(a *arc) {
+
+	lArc := a.previous
+	rArc := a.next
+	if lArc == nil || rArc == nil {
+		return
+	}
+
+	lSite := lArc.site
+	cSite := a.site
+	rSite := rArc.site
+	if lSite == rSite {
+		return
+	}
+
+	bx := cSite.x
+	by := cSite.y
+	ax := lSite.x - bx
+	ay := lSite.y - by
+	cx := rSite.x - bx
+	cy := rSite.y - by
+
+	// the breakpoints don't converge (the sites are clockwise or collinear)
+	d := 2 * (ax*cy - ay*cx)
+	if d >= -2e-12 {
+		return
+	}
+
+	ha := ax*ax + ay*ay
+	hc := cx*cx + cy*cy
+	x := (cy*ha - ay*hc) / d
+	y := (ax*hc - cx*ha) / d
+	yCenter := y + by
+
+	circle := &circleEvent{
+		arc:     a,
+		x:       x + bx,
+		y:       yCenter + math.Sqrt(x*x+y*y),
+		yCenter: yCenter,
+		seq:     s.seq,
+	}
+	s.seq++
+
+	a.circle = circle
+	heap.Push(&s.circles, circle)
+}
+
+// detachCircle removes the circle event of the arc from the queue, if any
+func (s *sweep) detachCircle(a *arc) {
+	if a.circle != nil {
+		if a.circle.index >= 0 {
+			heap.Remove(&s.circles, a.circle.index)
+		}
+		a.circle = nil
+	}
+}
+
+// connectEdge gives an endpoint to the dangling edges, on the border of the bounding box.
+// It returns false if the edge lays outside the bounding box
+func (s *sweep) connectEdge(edge *sweepEdge, box bbox) bool {
+
+	vb := edge.vb
+	if vb != nil {
+		return true
+	}
+
+	va := edge.va
+	lSite := edge.lSite
+	rSite := edge.rSite
+	lx := lSite.x
+	ly := lSite.y
+	rx := rSite.x
+	ry := rSite.y
+	fx := (lx + rx) / 2
+	fy := (ly + ry) / 2
+
+	s.cells[lSite.index].closeMe = true
+	s.cells[rSite.index].closeMe = true
+
+	// the edge lays on the bisector of the two sites, the line y = fm*x + fb
+	if ry == ly {
+		// vertical bisector
+		if fx < box.xl || fx >= box.xr {
+			return false
+		}
+		if lx > rx {
+			if va == nil || va.y < box.yt {
+				va = &sweepVertex{x: fx, y: box.yt}
+			} else if va.y >= box.yb {
+				return false
+			}
+			vb = &sweepVertex{x: fx, y: box.yb}
+		} else {
+			if va == nil || va.y > box.yb {
+				va = &sweepVertex{x: fx, y: box.yb}
+			} else if va.y < box.yt {
+				return false
+			}
+			vb = &sweepVertex{x: fx, y: box.yt}
+		}
+
+	} else {
+		fm := (lx - rx) / (ry - ly)
+		fb := fy - fm*fx
+
+		if fm < -1 || fm > 1 {
+			// closer to vertical than to horizontal: connect to the top and bottom sides
+			if lx > rx {
+				if va == nil || va.y < box.yt {
+					va = &sweepVertex{x: (box.yt - fb) / fm, y: box.yt}
+				} else if va.y >= box.yb {
+					return false
+				}
+				vb = &sweepVertex{x: (box.yb - fb) / fm, y: box.yb}
+			} else {
+				if va == nil || va.y > box.yb {
+					va = &sweepVertex{x: (box.yb - fb) / fm, y: box.yb}
+				} else if va.y < box.yt {
+					return false
+				}
+				vb = &sweepVertex{x: (box.yt - fb) / fm, y: box.yt}
+			}
+
+		} else {
+			// closer to horizontal than to vertical: connect to the left and right sides
+			if ly < ry {
+				if va == nil || va.x < box.xl {
+					va = &sweepVertex{x: box.xl, y: fm*box.xl + fb}
+				} else if va.x >= box.xr {
+					return false
+				}
+				vb = &sweepVertex{x: box.xr, y: fm*box.xr + fb}
+			} else {
+				if va == nil || va.x > box.xr {
+					va = &sweepVertex{x: box.xr, y: fm*box.xr + fb}
+				} else if va.x < box.xl {
+					return false
+				}
+				vb = &sweepVertex{x: box.xl, y: fm*box.xl + fb}
+			}
+		}
+	}
+
+	edge.va = va
+	edge.vb = vb
+	return true
+}
+
+// clipEdge clips the edge to the bounding box (Liang-Barsky algorithm).
+// It returns false if the edge lays outside the bounding box
+func (s *sweep) clipEdge(edge *sweepEdge, box bbox) bool {
+
+	ax := edge.va.x
+	ay := edge.va.y
+	bx := edge.vb.x
+	by := edge.vb.y
+	t0 := 0.0
+	t1 := 1.0
+	dx := bx - ax
+	dy := by - ay
+
+	// left
+	q := ax - box.xl
+	if dx == 0 && q < 0 {
+		return false
+	}
+	r := -q / dx
+	if dx < 0 {
+		if r < t0 {
+			return false
+		}
+		if r < t1 {
+			t1 = r
+		}
+	} else if dx > 0 {
+		if r > t1 {
+			return false
+		}
+		if r > t0 {
+			t0 = r
+		}
+	}
+
+	// right
+	q = box.xr - ax
+	if dx == 0 && q < 0 {
+		return false
+	}
+	r = q / dx
+	if dx < 0 {
+		if r > t1 {
+			return false
+		}
+		if r > t0 {
+			t0 = r
+		}
+	} else if dx > 0 {
+		if r < t0 {
+			return false
+		}
+		if r < t1 {
+			t1 = r
+		}
+	}
+
+	// top
+	q = ay - box.yt
+	if dy == 0 && q < 0 {
+		return false
+	}
+	r = -q / dy
+	if dy < 0 {
+		if r < t0 {
+			return false
+		}
+		if r < t1 {
+			t1 = r
+		}
+	} else if dy > 0 {
+		if r > t1 {
+			return false
+		}
+		if r > t0 {
+			t0 = r
+		}
+	}
+
+	// bottom
+	q = box.yb - ay
+	if dy == 0 && q < 0 {
+		return false
+	}
+	r = q / dy
+	if dy < 0 {
+		if r > t1 {
+			return false
+		}
+		if r > t0 {
+			t0 = r
+		}
+	} else if dy > 0 {
+		if r < t0 {
+			return false
+		}
+		if r < t1 {
+			t1 = r
+		}
+	}
+
+	if t0 > 0 {
+		edge.va = &sweepVertex{x: ax + t0*dx, y: ay + t0*dy}
+	}
+	if t1 < 1 {
+		edge.vb = &sweepVertex{x: ax + t1*dx, y: ay + t1*dy}
+	}
+	if t0 > 0 || t1 < 1 {
+		s.cells[edge.lSite.index].closeMe = true
+		s.cells[edge.rSite.index].closeMe = true
+	}
+
+	return true
+}
+
+// clipEdges connects the dangling edges and clips all the edges to the bounding box,
+// discarding the ones laying outside of it (or degenerated to a single point)
+func (s *sweep) clipEdges(box bbox) {
+
+	for _, edge := range s.edges {
+		if !s.connectEdge(edge, box) ||
+			!s.clipEdge(edge, box) ||
+			(math.Abs(edge.va.x-edge.vb.x) < epsilon && math.Abs(edge.va.y-edge.vb.y) < epsilon) {
+			edge.va = nil
+			edge.vb = nil
+		}
+	}
+}
+
+// closeCells sorts the half-edges of each cell around its site,
+// and closes the cells touching the bounding box by walking along its border
+func (s *sweep) closeCells(box bbox) {
+
+	for _, cell := range s.cells {
+		if cell == nil {
+			continue
+		}
+
+		// discard the half-edges whose edge has been clipped away
+		halfEdges := []*sweepHalfEdge{}
+		for _, h := range cell.halfEdges {
+			if h.edge.va != nil && h.edge.vb != nil {
+				halfEdges = append(halfEdges, h)
+			}
+		}
+		sort.SliceStable(halfEdges, func(i, j int) bool {
+			return halfEdges[i].angle > halfEdges[j].angle
+		})
+		cell.halfEdges = halfEdges
+
+		if len(halfEdges) == 0 || !cell.closeMe {
+			continue
+		}
+
+		for i := 0; i < len(cell.halfEdges); i++ {
+			va := cell.halfEdges[i].end()
+			vz := cell.halfEdges[(i+1)%len(cell.halfEdges)].start()
+
+			if math.Abs(va.x-vz.x) < epsilon && math.Abs(va.y-vz.y) < epsilon {
+				continue
+			}
+
+			// the half-edges are not connected: walk counterclockwise along the border
+			// from va to vz, adding the border edges
+			side := s.borderSide(va, box)
+			for turns := 0; turns <= 4; turns++ {
+
+				var vb *sweepVertex
+				last := false
+
+				switch side {
+				case 0: // downward along the left side
+					last = equalWithEpsilon(vz.x, box.xl)
+					vb = &sweepVertex{x: box.xl, y: box.yb}
+					if last {
+						vb.y = vz.y
+					}
+				case 1: // rightward along the bottom side
+					last = equalWithEpsilon(vz.y, box.yb)
+					vb = &sweepVertex{x: box.xr, y: box.yb}
+					if last {
+						vb.x = vz.x
+					}
+				case 2: // upward along the right side
+					last = equalWithEpsilon(vz.x, box.xr)
+					vb = &sweepVertex{x: box.xr, y: box.yt}
+					if last {
+						vb.y = vz.y
+					}
+				case 3: // leftward along the top side
+					last = equalWithEpsilon(vz.y, box.yt)
+					vb = &sweepVertex{x: box.xl, y: box.yt}
+					if last {
+						vb.x = vz.x
+					}
+				}
+
+				edge := &sweepEdge{lSite: cell.site, va: va, vb: vb}
+				s.edges = append(s.edges, edge)
+
+				i++
+				cell.halfEdges = append(cell.halfEdges, nil)
+				copy(cell.halfEdges[i+1:], cell.halfEdges[i:])
+				cell.halfEdges[i] = newSweepHalfEdge(edge, cell.site, nil)
+
+				if last {
+					break
+				}
+				va = vb
+				side = (side + 1) % 4
+			}
+		}
+		cell.closeMe = false
+	}
+}
+
+// borderSide returns the side of the bounding box where the walk along the border starting from v begins:
+// 0 left (walking downward), 1 bottom (walking rightward), 2 right (walking upward), 3 top (walking leftward)
+func (s *sweep) borderSide(v *sweepVertex, box bbox) int {
+	switch {
+	case equalWithEpsilon(v.x, box.xl) && lessThanWithEpsilon(v.y, box.yb):
+		return 0
+	case equalWithEpsilon(v.y, box.yb) && lessThanWithEpsilon(v.x, box.xr):
+		return 1
+	case equalWithEpsilon(v.x, box.xr) && greaterThanWithEpsilon(v.y, box.yt):
+		return 2
+	default:
+		return 3
+	}
+}
+
+// equalWithEpsilon compares two coordinates with the tolerance of the algorithm
+func equalWithEpsilon(a float64, b float64) bool {
+	return math.Abs(a-b) < epsilon
+}
+
+// lessThanWithEpsilon checks that a is less than b, beyond the tolerance of the algorithm
+func lessThanWithEpsilon(a float64, b float64) bool {
+	return b-a > epsilon
+}
+
+// greaterThanWithEpsilon checks that a is greater than b, beyond the tolerance of the algorithm
+func greaterThanWithEpsilon(a float64, b float64) bool {
+	return a-b > epsilon
+}
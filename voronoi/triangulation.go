@@ -0,0 +1,127 @@
+package voronoi
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Triangulation is the Delaunay triangulation of a set of seeds, dual of their voronoi diagram:
+// two seeds are connected when their cells share an edge
+type Triangulation struct {
+	Vertices  []Vertex // position of each seed: the vertex indexes are the seed indexes
+	Triangles [][3]int // indexes of the corners of each triangle, counterclockwise as seen on the canvas (y grows downward)
+}
+
+// Triangulate computes the Delaunay triangulation of the seeds, with the same sweep of the Fortune engine.
+// Duplicated seeds are part of the triangulation only once (with the index of the first one)
+func Triangulate(seeds []Point) *Triangulation {
+
+	t := &Triangulation{
+		Vertices:  []Vertex{},
+		Triangles: [][3]int{},
+	}
+	if len(seeds) == 0 {
+		return t
+	}
+
+	sites := []*site{}
+	box := bbox{xl: math.Inf(1), xr: math.Inf(-1), yt: math.Inf(1), yb: math.Inf(-1)}
+	for i, s := range seeds {
+		x := float64(s.X)
+		y := float64(s.Y)
+		t.Vertices = append(t.Vertices, Vertex{X: x, Y: y})
+		sites = append(sites, &site{x: x, y: y, index: i})
+
+		box.xl = math.Min(box.xl, x-1)
+		box.xr = math.Max(box.xr, x+1)
+		box.yt = math.Min(box.yt, y-1)
+		box.yb = math.Max(box.yb, y+1)
+	}
+
+	// the triangles don't depend on the bounding box, that only clips the cells
+	sw := computeSweep(sites, len(seeds), box)
+	t.Triangles = append(t.Triangles, sw.triangles...)
+
+	return t
+}
+
+// Triangulation returns the Delaunay triangulation of the seeds, dual of the diagram (nil if the tessellation is not computed yet).
+// On a toroidal canvas, the triangulation doesn't wrap around the borders
+func (f *Fortune) Triangulation() *Triangulation {
+
+	if f.dcel == nil {
+		return nil
+	}
+	return Triangulate(f.seeds)
+}
+
+// Edges returns the edges of the triangulation, each one only once, as the indexes of their ends
+func (t *Triangulation) Edges() [][2]int {
+
+	edges := [][2]int{}
+	found := map[[2]int]bool{}
+
+	for _, triangle := range t.Triangles {
+		for i := range triangle {
+			edge := [2]int{triangle[i], triangle[(i+1)%3]}
+			if edge[0] > edge[1] {
+				edge[0], edge[1] = edge[1], edge[0]
+			}
+			if !found[edge] {
+				found[edge] = true
+				edges = append(edges, edge)
+			}
+		}
+	}
+
+	return edges
+}
+
+// WriteOBJ exports the triangulation as a Wavefront OBJ mesh laying on the z=0 plane.
+// The faces are counterclockwise as seen on the canvas, so the y axis of the mesh points downward
+func (t *Triangulation) WriteOBJ(w io.Writer) error {
+
+	b := bufio.NewWriter(w)
+
+	fmt.Fprintf(b, "# Delaunay triangulation: %d vertices, %d triangles\n", len(t.Vertices), len(t.Triangles))
+	for _, v := range t.Vertices {
+		fmt.Fprintf(b, "v %g %g 0\n", v.X, v.Y)
+	}
+
+	// the OBJ indexes start from 1
+	for _, triangle := range t.Triangles {
+		fmt.Fprintf(b, "f %d %d %d\n", triangle[0]+1, triangle[1]+1, triangle[2]+1)
+	}
+
+	return b.Flush()
+}
+
+/*
+WriteMesh exports the triangulation in a simple text format
+
+The first line contains the number of vertices and the number of triangles,
+followed by a line for each vertex (its coordinates) and a line for each triangle
+(the indexes of its corners, starting from 0):
+
+	3 1
+	10 20
+	40 25
+	30 50
+	0 2 1
+*/
+func (t *Triangulation) WriteMesh(w io.Writer) error {
+
+	b := bufio.NewWriter(w)
+
+	fmt.Fprintf(b, "%d %d\n", len(t.Vertices), len(t.Triangles))
+	for _, v := range t.Vertices {
+		fmt.Fprintf(b, "%g %g\n", v.X, v.Y)
+	}
+	for _, triangle := range t.Triangles {
+		fmt.Fprintf(b, "%d %d %d\n", triangle[0], triangle[1], triangle[2])
+	}
+
+	return b.Flush()
+}
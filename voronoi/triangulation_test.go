@@ -0,0 +1,125 @@
+package voronoi
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// TestTriangulate checks that the triangulation of random seeds (on a small canvas, so with many collinear and cocircular ones)
+// is a Delaunay one: no seed is inside the circumcircle of a triangle, and the triangles cover the convex hull of the seeds,
+// that is they are 2n-2-h for n distinct seeds, h of them on the border of the hull
+func TestTriangulate(t *testing.T) {
+
+	r := rand.New(rand.NewSource(11))
+	for _, n := range []int{3, 4, 10, 50, 300} {
+
+		seeds := []Point{}
+		for i := 0; i < n; i++ {
+			seeds = append(seeds, Point{X: r.Intn(40), Y: r.Intn(30)})
+		}
+		seeds = append(seeds, seeds[0]) // a duplicated seed
+
+		// the distinct seeds, as the first index of each position
+		distinct := []int{}
+		first := map[int]bool{}
+		found := map[[2]int]bool{}
+		for i, s := range seeds {
+			if !found[[2]int{s.X, s.Y}] {
+				found[[2]int{s.X, s.Y}] = true
+				first[i] = true
+				distinct = append(distinct, i)
+			}
+		}
+
+		triangulation := Triangulate(seeds)
+		h := hullBorder(seeds, distinct)
+		if h == len(distinct) && len(triangulation.Triangles) == 0 {
+			continue // all the seeds are collinear
+		}
+		if expected := 2*len(distinct) - 2 - h; len(triangulation.Triangles) != expected {
+			t.Fatalf("%d seeds, %d on the hull: %d triangles instead of %d", len(distinct), h, len(triangulation.Triangles), expected)
+		}
+
+		for _, triangle := range triangulation.Triangles {
+			a, b, c := seeds[triangle[0]], seeds[triangle[1]], seeds[triangle[2]]
+			// counterclockwise as seen on the canvas, with the y axis pointing downward
+			if orientation(a, b, c) >= 0 {
+				t.Fatalf("triangle %v is not counterclockwise on the canvas", triangle)
+			}
+			for _, i := range distinct {
+				if inCircle(a, b, c, seeds[i]) {
+					t.Fatalf("seed %d %v is inside the circumcircle of the triangle %v", i, seeds[i], triangle)
+				}
+			}
+			for _, i := range triangle {
+				if !first[i] {
+					t.Fatalf("triangle %v uses the duplicated seed %d %v", triangle, i, seeds[i])
+				}
+			}
+		}
+	}
+}
+
+// orientation returns the cross product of b-a and c-a: positive if a, b, c turn counterclockwise with the y axis pointing up
+func orientation(a, b, c Point) int {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+// inCircle tells whether d is strictly inside the circumcircle of the triangle a, b, c (clockwise with the y axis pointing up)
+func inCircle(a, b, c, d Point) bool {
+
+	ax, ay := int64(a.X-d.X), int64(a.Y-d.Y)
+	bx, by := int64(b.X-d.X), int64(b.Y-d.Y)
+	cx, cy := int64(c.X-d.X), int64(c.Y-d.Y)
+	det := (ax*ax+ay*ay)*(bx*cy-cx*by) - (bx*bx+by*by)*(ax*cy-cx*ay) + (cx*cx+cy*cy)*(ax*by-bx*ay)
+	return det < 0
+}
+
+// hullBorder returns the number of the seeds (among the given ones) on the border of their convex hull,
+// including the ones inside its sides
+func hullBorder(seeds []Point, indexes []int) int {
+
+	points := []Point{}
+	for _, i := range indexes {
+		points = append(points, seeds[i])
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].X < points[j].X || points[i].X == points[j].X && points[i].Y < points[j].Y
+	})
+
+	// monotone chain, keeping the collinear points out of the corners
+	hull := []Point{}
+	for _, pass := range [][]Point{points, reversed(points)} {
+		start := len(hull)
+		for _, p := range pass {
+			for len(hull) >= start+2 && orientation(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+				hull = hull[:len(hull)-1]
+			}
+			hull = append(hull, p)
+		}
+		hull = hull[:len(hull)-1]
+	}
+
+	border := 0
+	for _, p := range points {
+		for i := range hull {
+			a, b := hull[i], hull[(i+1)%len(hull)]
+			if orientation(a, b, p) == 0 && (p.X-a.X)*(p.X-b.X) <= 0 && (p.Y-a.Y)*(p.Y-b.Y) <= 0 {
+				border++
+				break
+			}
+		}
+	}
+	return border
+}
+
+// reversed returns the points in the opposite order
+func reversed(points []Point) []Point {
+
+	r := []Point{}
+	for i := len(points) - 1; i >= 0; i-- {
+		r = append(r, points[i])
+	}
+	return r
+}
@@ -0,0 +1,552 @@
+// Package voronoi computes voronoi diagrams on a pixel canvas.
+//
+// The engines of this package don't depend on any graphics library:
+// the result can be obtained as a Diagram or as a RGBA byte sequence ready to be rendered
+package voronoi
+
+import (
+	"math"
+)
+
+// Voronoi is the engine used to generate a voronoi diagram on a canvas, starting from auto-generated seed points
+type Voronoi struct {
+
+	// diagram size (in pixels)
+	width  int
+	height int
+
+	// seed configuration of the diagram
+	numSeeds int     // number of seeds for the diagram
+	seeds    []Point // list of seeds for the diagram
+
+	radius      int   // current radius of the computation
+	activeSeeds []int // indexes of the active seeds to take into account for the computation
+
+	unassigned int     // number of pixels not assigned to any seed yet
+	bound      float64 // upper bound of the distances of the pixels from their seeds (used by the weighted diagrams)
+
+	distances [][]float64 // precomputed distances matrix (for efficiency reasons)
+
+	diagram [][]*Point // resulting diagram (initially empty, to be computed)
+	labels  []int      // id of the seed owning each pixel, row by row (-1 if not assigned yet)
+
+	// the seeds keep their ids while the seeds before them are removed (see RemoveSeed), so the pixels don't need to be relabeled
+	ids     []int     // id of each seed
+	indexes []int     // index of the seed with each id (-1 if removed)
+	boxes   []cellBox // bounding box of the cell of each seed, used by the seed edits (nil until the first edit)
+
+	config // optional settings (including the random generation of the seeds, see seeding)
+}
+
+// NewVoronoi creates a new diagram struct.
+// If explicit seeds are provided through the options, numSeeds is ignored
+func NewVoronoi(
+	width int,
+	height int,
+	numSeeds int,
+	opts ...Option,
+) (*Voronoi, error) {
+
+	c, numSeeds, err := setup(width, height, numSeeds, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Voronoi{
+		width:       width,
+		height:      height,
+		numSeeds:    numSeeds,
+		seeds:       []Point{},
+		radius:      0,
+		activeSeeds: []int{},
+		distances:   make([][]float64, 2*width+1),
+		diagram:     make([][]*Point, width),
+		labels:      make([]int, width*height),
+		config:      c,
+	}
+
+	// the distances only depend on the canvas and the metric, so they are computed once for all the seeds
+	v.initDistances()
+
+	return v, nil
+}
+
+// Init initializes the Voronoi diagram and generates a new set of seeds
+func (v *Voronoi) Init() {
+	v.initDiagram()
+	v.initSeeds()
+	v.initTessellation()
+}
+
+// initDistances populates the precomputed distances matrix (measured with the configured metric),
+// to avoid recomputing the same distance values over and over
+func (v *Voronoi) initDistances() {
+
+	// the distance vectors needed by the engine can assume values up to twice their dimension  (2*width or 2*height)
+	for i := 0; i <= 2*v.width; i++ {
+
+		column := make([]float64, 2*v.height+1)
+		v.distances[i] = column
+
+		for j := 0; j <= 2*v.height; j++ {
+			v.distances[i][j] = v.config.metric.Distance(i, j)
+		}
+	}
+}
+
+// initDiagram populates the diagram with empty points
+func (v *Voronoi) initDiagram() {
+
+	v.unassigned = v.width * v.height
+
+	for i := 0; i < v.width; i++ {
+
+		column := make([]*Point, v.height)
+		v.diagram[i] = column
+
+		for j := 0; j < v.height; j++ {
+			v.diagram[i][j] = nil
+			v.labels[j*v.width+i] = -1
+		}
+	}
+}
+
+// SetSeeds replaces the seeds of the diagram, and restarts the tessellation
+func (v *Voronoi) SetSeeds(seeds []Point) error {
+
+	s, err := replaceSeeds(v.width, v.height, seeds, v.config)
+	if err != nil {
+		return err
+	}
+
+	v.initDiagram()
+	v.placeSeeds(s)
+	v.initTessellation()
+
+	return nil
+}
+
+// initSeeds generates the set of seeds and stores them in the diagram
+func (v *Voronoi) initSeeds() {
+	v.placeSeeds(generateSeeds(v.width, v.height, v.numSeeds, v.config))
+}
+
+// placeSeeds stores the seeds in the diagram, as the first pixels of their cells
+func (v *Voronoi) placeSeeds(seeds []Point) {
+
+	v.seeds = seeds
+	v.ids = make([]int, len(seeds))
+	v.indexes = make([]int, len(seeds))
+	v.boxes = nil
+
+	for i, seed := range v.seeds {
+		v.ids[i] = i
+		v.indexes[i] = i
+
+		s := seed
+		if v.diagram[s.X][s.Y] == nil {
+			v.unassigned--
+
+		} else if *v.diagram[s.X][s.Y].Distance < *s.Distance {
+			// with power distances, a duplicated seed with a larger radius keeps the pixel
+			continue
+		}
+		v.diagram[s.X][s.Y] = &s
+		v.labels[s.Y*v.width+s.X] = i
+	}
+}
+
+// initTessellation starts the tessellation of the existing set of seeds
+func (v *Voronoi) initTessellation() {
+
+	v.radius = 0
+	v.bound = math.Inf(1)
+	v.activeSeeds = []int{}
+	for i := range v.seeds {
+		v.activeSeeds = append(v.activeSeeds, i)
+	}
+
+	// fmt.Println("#######################################")
+	// fmt.Println("#### Voronoi tessellation starting ####")
+	// fmt.Println("#######################################")
+}
+
+/*
+Tessellate computes the voronoi diagram
+
+It works on a list of 'active' seeds, where 'active' means that the seed can still extend its area.
+At each iteration, the area of the cell corresponding to each seed gets extended by 1 pixel,
+and each of these pixels gets assigned to that cell (unless it already belongs to a nearest seed)
+
+Without weights, a seed stops as soon as a new layer adds nothing to its cell, as the cells are connected.
+Weighted cells can be disconnected or surround other cells, so a layer adding nothing doesn't mean
+the next ones won't add anything: a weighted seed stops only when its distance from the next layer
+exceeds the largest distance assigned in the diagram, so that none of its pixels can be taken anymore
+
+Each layer is computed in parallel on bands of rows (see WithWorkers)
+*/
+func (v *Voronoi) Tessellate(hideIterations bool) error {
+
+	// the tessellation goes on until all the seeds have extended their area as much as possible
+	for len(v.activeSeeds) > 0 {
+
+		stillActiveSeeds := []int{}
+		v.radius++ // increment the radius of the cells
+		v.updateBound()
+
+		// extend the area of each active seed
+		grown := v.extendLayer()
+
+		for i, seed := range v.activeSeeds {
+
+			// stillActive monitors if the current seed is still able to extend its area
+			stillActive := grown[i]
+
+			// weighted seeds go on as long as the next layer may still contain any pixel of their cell
+			if v.config.weighting != Unweighted {
+				stillActive = v.canExtend(seed, v.radius, v.bound)
+			}
+
+			// populate the list of the seeds that are still active
+			if stillActive {
+				stillActiveSeeds = append(stillActiveSeeds, seed)
+			}
+		}
+
+		v.activeSeeds = stillActiveSeeds
+
+		if !hideIterations {
+			// this breaks the computation to the current state of the tessellation,
+			// useful to show the evolution of the diagram
+			break
+		}
+	}
+
+	return nil
+}
+
+// Done reports whether the tessellation is complete, i.e. no seed can extend its area anymore
+func (v *Voronoi) Done() bool {
+	return len(v.activeSeeds) == 0
+}
+
+/*
+extendLayer tries to assign the pixels of the layer at the current radius to each active seed,
+and reports which of the active seeds got any of them.
+
+The canvas is split in bands of rows computed in parallel: each band takes the active seeds in order,
+so each pixel sees the seeds in the same order of a sequential computation, and the ties between them
+are broken the same way. This way the diagram doesn't depend on the number of workers
+*/
+func (v *Voronoi) extendLayer() []bool {
+
+	workers := v.config.numWorkers()
+	grown := make([][]bool, workers)
+	claimed := make([]int, workers) // pixels assigned for the first time in each band
+
+	parallelRows(v.height, workers, func(band int, from int, to int) {
+		grown[band] = make([]bool, len(v.activeSeeds))
+		for i, seed := range v.activeSeeds {
+			grown[band][i], claimed[band] = v.extendRows(seed, from, to, claimed[band])
+		}
+	})
+
+	result := make([]bool, len(v.activeSeeds))
+	for band := range grown {
+		for i := range grown[band] {
+			result[i] = result[i] || grown[band][i]
+		}
+		v.unassigned -= claimed[band]
+	}
+	return result
+}
+
+/*
+extendRows tries to assign to a seed (given its index) the pixels of the layer at the current radius
+laying in the rows from..to (excluded), and reports whether it got any of them.
+It also returns the count of the pixels assigned for the first time, increased by the ones of this seed.
+
+The layer is made of the offsets (dx, dy) with |dx|+|dy| equal to the radius, so each row holds up to 2 of its pixels.
+On a toroidal canvas the rows wrap around the borders: the offsets are limited to half the canvas (see assignPointToSeed),
+so each row of the band is reached by a single offset dy (or by two opposite ones at half the canvas, giving the same pixels)
+*/
+func (v *Voronoi) extendRows(seedIndex int, from int, to int, claimed int) (bool, int) {
+
+	seed := v.seeds[seedIndex]
+	grown := false
+
+	// the offsets dy reaching the rows of the band, also across the borders on a toroidal canvas
+	shifts := []int{0}
+	limit := v.radius
+	if v.config.toroidal {
+		shifts = []int{-v.height, 0, v.height}
+		limit = minInt(limit, v.height/2)
+	}
+
+	for _, shift := range shifts {
+		first := maxInt(from-seed.Y+shift, -limit)
+		last := minInt(to-1-seed.Y+shift, limit)
+
+		for dy := first; dy <= last; dy++ {
+			dx := v.radius - abs(dy)
+			for _, offset := range []int{dx, -dx} {
+				// the pixels out of the canvas are skipped before measuring their distance:
+				// on a narrow canvas the layers reach offsets beyond the precomputed distances
+				if _, _, inside := v.pixelAt(seedIndex, offset, dy); inside {
+					assigned, unassigned := v.assignPixel(seedIndex, v.distance(seedIndex, offset, dy), offset, dy)
+					grown = assigned || grown
+					if unassigned {
+						claimed++
+					}
+				}
+				if dx == 0 {
+					break
+				}
+			}
+		}
+	}
+
+	return grown, claimed
+}
+
+// assignPointToSeed tries to assign a point to a seed (given its index) using the relative coordinates
+func (v *Voronoi) assignPointToSeed(seedIndex int, distance float64, dx int, dy int) bool {
+
+	assigned, unassigned := v.assignPixel(seedIndex, distance, dx, dy)
+	if unassigned {
+		v.unassigned--
+	}
+	return assigned
+}
+
+// assignPixel tries to assign a point to a seed (given its index) using the relative coordinates, as assignPointToSeed does.
+// It reports whether the point got assigned, and whether it was unassigned before, without updating the count of the unassigned pixels:
+// it only changes the point itself, so the points of different rows can be assigned in parallel
+func (v *Voronoi) assignPixel(seedIndex int, distance float64, dx int, dy int) (bool, bool) {
+
+	seed := v.seeds[seedIndex]
+	x, y, inside := v.pixelAt(seedIndex, dx, dy)
+	if !inside {
+		return false, false
+	}
+
+	// get the point from the struct containing the resulting diagram representation
+	p := v.pointFromDiagram(x, y)
+
+	// if the point is already assigned to a cell whose seed is closer, ignore it
+	if p.Distance != nil && *p.Distance < distance {
+		// fmt.Println(fmt.Sprintf("Point (%d,%d) has already a smaller distance (%d < %d), discarded", seed.X+dx, seed.Y+dy, *p.Distance, distance))
+		return false, false
+	}
+
+	// the point can be assigned to the seed and stored in the resulting diagram representation
+	// fmt.Println(fmt.Sprintf("Assigning point (%d,%d) to cell with seed (%d, %d). Distance: %d", p.X, p.Y, seed.X, seed.Y, distance))
+	unassigned := p.Distance == nil
+	p.Color = seed.Color
+	p.Distance = &distance
+	v.diagram[p.X][p.Y] = &p
+	v.labels[p.Y*v.width+p.X] = v.ids[seedIndex]
+
+	return true, unassigned
+}
+
+// pixelAt returns the pixel at the relative coordinates from a seed (given its index), and whether it is reached by the layers of the seed
+func (v *Voronoi) pixelAt(seedIndex int, dx int, dy int) (int, int, bool) {
+
+	seed := v.seeds[seedIndex]
+	x := seed.X + dx
+	y := seed.Y + dy
+
+	if v.config.toroidal {
+		// on a toroidal canvas the point wraps around the borders,
+		// unless it's closer to the seed going the other way (it's reached by another layer, then)
+		if 2*abs(dx) > v.width || 2*abs(dy) > v.height {
+			return 0, 0, false
+		}
+		return mod(x, v.width), mod(y, v.height), true
+	}
+
+	// if the point is outside the diagram, ignore it
+	// fmt.Println(fmt.Sprintf("Point (%d,%d) out of canvas, discarded", seed.X+dx, seed.Y+dy))
+	return x, y, x >= 0 && x < v.width && y >= 0 && y < v.height
+}
+
+// distance computes the distance between a seed (given its index) and the point at the relative coordinates
+func (v *Voronoi) distance(seedIndex int, dx int, dy int) float64 {
+
+	// without weights, the distance only depends on the relative coordinates
+	if v.config.weighting == Unweighted {
+		return v.distances[abs(dx)][abs(dy)]
+	}
+	return v.config.distance(v.seeds[seedIndex], dx, dy)
+}
+
+// updateBound refreshes the upper bound of the distances assigned in the diagram.
+// The distances can only decrease during the tessellation, so an outdated bound is still valid:
+// it is refreshed only every few layers, as it takes a full scan of the diagram
+func (v *Voronoi) updateBound() {
+
+	if v.config.weighting == Unweighted {
+		return
+	}
+
+	// until every pixel is assigned, any seed may still reach a free pixel
+	if v.unassigned > 0 {
+		v.bound = math.Inf(1)
+		return
+	}
+	if !math.IsInf(v.bound, 1) && v.radius%8 != 0 {
+		return
+	}
+
+	v.bound = v.maxDistance()
+}
+
+// maxDistance scans the diagram for the largest distance of a pixel from its seed (every pixel must be assigned)
+func (v *Voronoi) maxDistance() float64 {
+
+	bound := math.Inf(-1)
+	for i := 0; i < v.width; i++ {
+		for j := 0; j < v.height; j++ {
+			bound = math.Max(bound, *v.diagram[i][j].Distance)
+		}
+	}
+	return bound
+}
+
+// canExtend checks if the layer after the given radius of a weighted seed (given its index) may still contain any pixel of its cell:
+// the layer must be inside the canvas, and not farther than the bound of the distances of the diagram
+func (v *Voronoi) canExtend(seedIndex int, radius int, bound float64) bool {
+
+	seed := v.seeds[seedIndex]
+
+	// the distance (measured along the axes) of the farthest corner of the canvas
+	reach := maxInt(seed.X, v.width-1-seed.X) + maxInt(seed.Y, v.height-1-seed.Y)
+	if v.config.toroidal {
+		reach = v.width/2 + v.height/2
+	}
+	if radius+1 > reach {
+		return false
+	}
+
+	return v.config.lowerBound(seed, radius+1) <= bound
+}
+
+/*
+layer
+
+It returns a list of points, intended as coordinates relative to the seed,
+that represents the layer of pixels at the given radius of the expanding cell
+(just the seed itself at radius 0). It's used to grow a single cell (see AddSeed).
+
+It works by computing a 45° diagonal that has an horizontal (so not orthogonal!)
+distance from the seed equal to the radius.
+This diagonal is one segment (out of 8) of the diamond surrounding the seed: to compute all
+the other segments and get the complete diamond, the algorithm generates all the possible
+combinations of the relative coordinates
+*/
+func layer(radius int) []Point {
+
+	if radius == 0 {
+		return []Point{{X: 0, Y: 0}}
+	}
+	combinations := []Point{}
+
+	// initialize the relative coordinates that will be the first edge of the segment
+	dx := radius
+	dy := 0
+
+	// go on until the other edge of the segment is reached
+	for dx >= dy {
+		combinations = append(combinations, Point{X: dx, Y: dy})
+		combinations = append(combinations, Point{X: dx, Y: -dy})
+		combinations = append(combinations, Point{X: -dx, Y: dy})
+		combinations = append(combinations, Point{X: -dx, Y: -dy})
+		combinations = append(combinations, Point{X: dy, Y: dx})
+		combinations = append(combinations, Point{X: dy, Y: -dx})
+		combinations = append(combinations, Point{X: -dy, Y: dx})
+		combinations = append(combinations, Point{X: -dy, Y: -dx})
+
+		// update the relative coordinates to the next point of the segment
+		dx--
+		dy++
+	}
+
+	return combinations
+}
+
+// pointFromDiagram gets the point of the diagram corresponding to the given coordinates
+func (v *Voronoi) pointFromDiagram(x int, y int) Point {
+	if v.diagram[x][y] == nil {
+		v.diagram[x][y] = &Point{
+			X: x,
+			Y: y,
+		}
+	}
+
+	return *v.diagram[x][y]
+}
+
+// ToPixels generates the byte array containing the information to render the diagram.
+// Each row of the canvas is concatenated to obtain a one-dimensional array.
+// Each pixel is represented by 4 bytes, representing the Red, Green, Blue and Alpha info.
+// The rows are filled in parallel (see WithWorkers)
+func (v *Voronoi) ToPixels() []byte {
+	pixels := make([]byte, v.width*v.height*4)
+
+	// iterate through each pixel, in bands of rows
+	parallelRows(v.height, v.config.numWorkers(), func(band int, from int, to int) {
+		for j := from; j < to; j++ {
+			for i := 0; i < v.width; i++ {
+				pos := (j*v.width + i) * 4
+
+				if v.diagram[i][j] != nil && v.diagram[i][j].Color != nil {
+					pixels[pos] = v.diagram[i][j].Color.R
+					pixels[pos+1] = v.diagram[i][j].Color.G
+					pixels[pos+2] = v.diagram[i][j].Color.B
+					pixels[pos+3] = v.diagram[i][j].Color.A
+
+				} else {
+					// if the point has not assigned any color yet, show it as black
+					pixels[pos] = 0
+					pixels[pos+1] = 0
+					pixels[pos+2] = 0
+					pixels[pos+3] = 0
+				}
+			}
+		}
+	})
+
+	// iterate through the seeds to render them as black points
+	drawSeeds(pixels, v.width, v.height, v.seeds, v.config.weighting, v.config.toroidal)
+
+	return pixels
+}
+
+// Diagram returns a snapshot of the current state of the tessellation
+func (v *Voronoi) Diagram() *Diagram {
+	d := newDiagram(v.width, v.height, v.seeds, v.config)
+	for pos, id := range v.labels {
+		if id >= 0 {
+			d.Labels[pos] = v.indexes[id]
+		}
+	}
+
+	for i := 0; i < v.width; i++ {
+		for j := 0; j < v.height; j++ {
+			if v.diagram[i][j] != nil && v.diagram[i][j].Distance != nil {
+				d.Distances[j*v.width+i] = *v.diagram[i][j].Distance
+			}
+		}
+	}
+
+	return d
+}
+
+// abs is a utility function to compute the absolute value of an int
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,59 @@
+package voronoi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Weighting is the way the weights of the seeds affect the distances
+type Weighting int
+
+const (
+	// Unweighted ignores the weights: the distance is the one measured by the metric
+	Unweighted Weighting = iota
+
+	// Multiplicative divides the distance from a seed by its weight:
+	// the boundaries between the cells become curves (Apollonius circles),
+	// and the cells of the heavier seeds may surround the other cells or even be disconnected
+	Multiplicative
+
+	// Power measures the power distance |p-s|^2 - r^2, where r is the radius of the seed (Laguerre-Voronoi diagram):
+	// the cells are still convex polygons, but a seed may be left with an empty cell.
+	// It is defined only for the euclidean metric
+	Power
+)
+
+func (w Weighting) String() string {
+	switch w {
+	case Unweighted:
+		return "none"
+	case Multiplicative:
+		return "multiplicative"
+	case Power:
+		return "power"
+	default:
+		return fmt.Sprintf("Weighting(%d)", int(w))
+	}
+}
+
+// ParseWeighting returns the weighting with the given name (none, multiplicative or power)
+func ParseWeighting(name string) (Weighting, error) {
+	switch strings.ToLower(name) {
+	case "none", "":
+		return Unweighted, nil
+	case "multiplicative":
+		return Multiplicative, nil
+	case "power":
+		return Power, nil
+	default:
+		return Unweighted, fmt.Errorf("Unknown weighting %q", name)
+	}
+}
+
+// weight returns the weight of a seed (1 if not set)
+func (p Point) weight() float64 {
+	if p.Weight == 0 {
+		return 1
+	}
+	return p.Weight
+}
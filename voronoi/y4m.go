@@ -0,0 +1,78 @@
+package voronoi
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// y4mWriter encodes an uncompressed YUV4MPEG2 video
+type y4mWriter struct {
+	w      *bufio.Writer
+	fps    int
+	size   image.Point // size of the frames, taken from the first one
+	frames int
+	planes []byte // buffer of the Y, Cb and Cr planes of a frame
+}
+
+/*
+NewY4MWriter creates an animation writer encoding an uncompressed YUV4MPEG2 video at the given frame rate,
+with full resolution chroma (4:4:4).
+The planes hold full range values (0-255, as converted by color.RGBToYCbCr), declared by the XCOLORRANGE=FULL tag of the header.
+The frames are written as soon as they are added, so the video can be piped into the video tools, e.g.:
+
+	./voronoi render -out - | ffmpeg -i - growth.mp4
+*/
+func NewY4MWriter(w io.Writer, fps int) AnimationWriter {
+	return &y4mWriter{
+		w:   bufio.NewWriter(w),
+		fps: fps,
+	}
+}
+
+// WriteFrame converts a frame to the YCbCr color space, and writes it
+func (y *y4mWriter) WriteFrame(frame *image.RGBA) error {
+
+	size := frame.Bounds().Size()
+	if y.frames == 0 {
+		y.size = size
+		y.planes = make([]byte, size.X*size.Y*3)
+		if _, err := fmt.Fprintf(y.w, "YUV4MPEG2 W%d H%d F%d:1 Ip A1:1 C444 XCOLORRANGE=FULL\n", size.X, size.Y, y.fps); err != nil {
+			return err
+		}
+	} else if size != y.size {
+		return errors.New("All the frames of the animation must have the same size")
+	}
+
+	pixels := size.X * size.Y
+	for row := 0; row < size.Y; row++ {
+		for x := 0; x < size.X; x++ {
+			p := frame.Pix[row*frame.Stride+x*4:]
+			pos := row*size.X + x
+			y.planes[pos], y.planes[pixels+pos], y.planes[2*pixels+pos] = color.RGBToYCbCr(p[0], p[1], p[2])
+		}
+	}
+
+	if _, err := y.w.WriteString("FRAME\n"); err != nil {
+		return err
+	}
+	if _, err := y.w.Write(y.planes); err != nil {
+		return err
+	}
+	y.frames++
+
+	// the frames are available to the reading end of a pipe as soon as they are complete
+	return y.w.Flush()
+}
+
+// Close completes the video
+func (y *y4mWriter) Close() error {
+
+	if y.frames == 0 {
+		return errors.New("The animation has no frames")
+	}
+	return y.w.Flush()
+}